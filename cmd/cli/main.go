@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
@@ -13,6 +14,7 @@ import (
 	"github.com/dzmitry-papkou/scraper/internal/cli"
 	"github.com/dzmitry-papkou/scraper/internal/config"
 	"github.com/dzmitry-papkou/scraper/internal/database"
+	"github.com/dzmitry-papkou/scraper/internal/scraper"
 )
 
 func main() {
@@ -23,6 +25,12 @@ func main() {
 		exportFlag  = flag.Bool("export", false, "Export data to CSV and exit")
 		scraperName = flag.String("scraper", "", "Specific scraper to use (overrides default)")
 		listFlag    = flag.Bool("list", false, "List available scrapers")
+		verboseFlag = flag.Bool("verbose", false, "Log every SQL statement before execution")
+		stdoutFlag  = flag.Bool("stdout", false, "Scrape once and print posts to stdout without a database")
+		formatFlag  = flag.String("format", "json", "Output format for -stdout: json or csv")
+		verifyFlag  = flag.Bool("verify", false, "Check the parser against the live site and exit non-zero on failure")
+		saveHTML    = flag.Bool("save-html", false, "Write each fetched page to the debug directory before parsing")
+		ignoreErrs  = flag.Bool("ignore-errors", false, "Exit 0 from -scrape, -analyze and -export even when the command fails")
 	)
 	flag.Parse()
 
@@ -39,14 +47,45 @@ func main() {
 		return
 	}
 
+	scraper.SetSaveHTML(*saveHTML || cfg.App.SaveHTML, cfg.App.DebugHTMLDir)
+
+	scraperToUse := cfg.App.DefaultScraper
+	if *scraperName != "" {
+		scraperToUse = *scraperName
+	}
+
+	if *verifyFlag {
+		s, err := scraper.NewGenericScraper(nil, scraperToUse)
+		if err != nil {
+			log.Fatal("Failed to create scraper:", err)
+		}
+		if !cli.RunSelfTest(s) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *stdoutFlag {
+		if err := scrapeToStdout(cfg, scraperToUse, *formatFlag); err != nil {
+			log.Fatal("Failed to scrape:", err)
+		}
+		return
+	}
+
+	database.SetVerbose(*verboseFlag || cfg.App.LogLevel == "debug")
+
 	if err := initDatabase(cfg); err != nil {
 		log.Fatal("Failed to initialize database:", err)
 	}
 	defer database.Close()
 
-	scraperToUse := cfg.App.DefaultScraper
-	if *scraperName != "" {
-		scraperToUse = *scraperName
+	if err := database.Migrate(); err != nil {
+		log.Fatal("Failed to migrate database:", err)
+	}
+	for _, sc := range cfg.Scrapers {
+		if err := database.EnsurePostsTable(sc.Table); err != nil {
+			log.Fatalf("Failed to prepare table for scraper %s: %v", sc.Name, err)
+		}
 	}
 
 	repo := database.NewRepository()
@@ -55,16 +94,22 @@ func main() {
 		log.Fatal("Failed to initialize commander:", err)
 	}
 
-	if *scrapeFlag {
-		commander.ExecuteCommand("scrape", nil)
-		return
+	oneShot := ""
+	switch {
+	case *scrapeFlag:
+		oneShot = "scrape"
+	case *analyzeFlag:
+		oneShot = "analyze"
+	case *exportFlag:
+		oneShot = "export"
 	}
-	if *analyzeFlag {
-		commander.ExecuteCommand("analyze", nil)
-		return
-	}
-	if *exportFlag {
-		commander.ExecuteCommand("export", nil)
+	if oneShot != "" {
+		if err := commander.RunOnce(oneShot); err != nil && !*ignoreErrs {
+			fmt.Fprintf(os.Stderr, "%s failed: %v\n", oneShot, err)
+			// os.Exit skips deferred calls
+			database.Close()
+			os.Exit(1)
+		}
 		return
 	}
 
@@ -72,6 +117,40 @@ func main() {
 	startInteractiveMode(commander, cfg)
 }
 
+// scrapeToStdout parses one page and prints it without touching postgres;
+// logs go to stderr so the output can be piped
+func scrapeToStdout(cfg *config.Config, scraperName, format string) error {
+	s, err := scraper.NewGenericScraper(nil, scraperName)
+	if err != nil {
+		return err
+	}
+
+	posts, err := s.Fetch()
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "json":
+		// one object per line, the same shape the import command reads
+		encoder := json.NewEncoder(os.Stdout)
+		for _, post := range posts {
+			if err := encoder.Encode(post); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "csv":
+		exporter := cli.NewExporter(nil)
+		if err := exporter.SetColumns(cfg.App.ExportColumns); err != nil {
+			return err
+		}
+		return exporter.WritePostsCSV(os.Stdout, posts)
+	default:
+		return fmt.Errorf("unknown format %q (expected json or csv)", format)
+	}
+}
+
 func loadConfig(path string) error {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		execPath, _ := os.Executable()
@@ -89,17 +168,30 @@ func loadConfig(path string) error {
 }
 
 func initDatabase(cfg *config.Config) error {
+	port := cfg.Database.Port
+	if port == 0 {
+		port = 5432
+	}
+
 	dbConfig := database.Config{
-		Host:     getEnv("DB_HOST", "localhost"),
-		Port:     5432,
-		User:     getEnv("DB_USER", "scraperuser"),
-		Password: getEnv("DB_PASSWORD", "supersecret"),
-		Database: getEnv("DB_NAME", "scraperdb"),
-		SSLMode:  "disable",
+		Host:             getEnv("DB_HOST", firstNonEmpty(cfg.Database.Host, "localhost")),
+		Port:             port,
+		User:             getEnv("DB_USER", firstNonEmpty(cfg.Database.User, "scraperuser")),
+		Password:         getEnv("DB_PASSWORD", firstNonEmpty(cfg.Database.Password, "supersecret")),
+		Database:         getEnv("DB_NAME", firstNonEmpty(cfg.Database.Name, "scraperdb")),
+		SSLMode:          cfg.Database.SSLMode,
+		ConnectTimeout:   cfg.Database.ConnectTimeout,
+		ApplicationName:  cfg.Database.ApplicationName,
+		SearchPath:       cfg.Database.SearchPath,
+		StatementTimeout: cfg.Database.StatementTimeout,
 	}
 
 	if cfg.Database.URL != "" {
-		return database.InitializeWithURL(cfg.Database.URL, 
+		dsn, err := dbConfig.WithParams(cfg.Database.URL)
+		if err != nil {
+			return err
+		}
+		return database.InitializeWithURL(dsn, 
 			cfg.Database.MaxConnections, 
 			cfg.Database.MaxIdle,
 			cfg.Database.ConnectionLifetime)
@@ -136,6 +228,15 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
 func printWelcome(cfg *config.Config) {
 	cyan := color.New(color.FgCyan).SprintFunc()
 	fmt.Println(cyan("╔══════════════════════════════════════════╗"))
@@ -149,6 +250,7 @@ func printWelcome(cfg *config.Config) {
 
 func startInteractiveMode(commander *cli.Commander, cfg *config.Config) {
 	scanner := bufio.NewScanner(os.Stdin)
+	commander.SetInput(scanner)
 	prompt := cfg.App.CLI.Prompt
 	if prompt == "" {
 		prompt = "➜"
@@ -158,7 +260,15 @@ func startInteractiveMode(commander *cli.Commander, cfg *config.Config) {
 
 	for {
 		fmt.Print(yellow("\n" + prompt + " "))
-		scanner.Scan()
+		if !scanner.Scan() {
+			// ctrl-d or the end of piped input
+			if err := scanner.Err(); err != nil {
+				log.Printf("Error reading input: %v", err)
+			}
+			fmt.Println()
+			commander.ExecuteCommand("quit", nil)
+			return
+		}
 		input := strings.TrimSpace(scanner.Text())
 
 		if input == "" {
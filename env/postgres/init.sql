@@ -6,10 +6,12 @@ CREATE TABLE IF NOT EXISTS posts (
     author VARCHAR(255) NOT NULL,
     points INTEGER DEFAULT 0,
     comments_count INTEGER DEFAULT 0,
-    post_time TIMESTAMP NOT NULL,
+    source VARCHAR(100) NOT NULL DEFAULT 'hackernews',
+    post_time TIMESTAMP,
     scraped_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_seen TIMESTAMP
 );
 
 CREATE TABLE IF NOT EXISTS post_history (
@@ -20,13 +22,22 @@ CREATE TABLE IF NOT EXISTS post_history (
     recorded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+CREATE TABLE IF NOT EXISTS post_title_history (
+    id SERIAL PRIMARY KEY,
+    post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    old_title TEXT NOT NULL,
+    new_title TEXT NOT NULL,
+    changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
 CREATE TABLE IF NOT EXISTS scraping_jobs (
     id SERIAL PRIMARY KEY,
     started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     completed_at TIMESTAMP,
     status VARCHAR(50) DEFAULT 'running',
     posts_scraped INTEGER DEFAULT 0,
-    error_message TEXT
+    error_message TEXT,
+    details JSONB
 );
 
 CREATE TABLE IF NOT EXISTS analysis_results (
@@ -44,10 +55,13 @@ CREATE INDEX IF NOT EXISTS idx_posts_author ON posts(author);
 CREATE INDEX IF NOT EXISTS idx_posts_points ON posts(points DESC);
 CREATE INDEX IF NOT EXISTS idx_posts_scraped_at ON posts(scraped_at DESC);
 CREATE INDEX IF NOT EXISTS idx_posts_updated_at ON posts(updated_at DESC);
+CREATE INDEX IF NOT EXISTS idx_posts_source ON posts(source);
 
 CREATE INDEX IF NOT EXISTS idx_post_history_post_id ON post_history(post_id);
 CREATE INDEX IF NOT EXISTS idx_post_history_recorded_at ON post_history(recorded_at DESC);
 
+CREATE INDEX IF NOT EXISTS idx_post_title_history_changed_at ON post_title_history(changed_at DESC);
+
 CREATE INDEX IF NOT EXISTS idx_scraping_jobs_status ON scraping_jobs(status);
 CREATE INDEX IF NOT EXISTS idx_scraping_jobs_started_at ON scraping_jobs(started_at DESC);
 
@@ -3,40 +3,63 @@ package analyzer
 import (
 	"database/sql"
 	"fmt"
+	"time"
+
 	"github.com/dzmitry-papkou/scraper/internal/database"
 	"github.com/dzmitry-papkou/scraper/internal/models"
+	"github.com/lib/pq"
 )
 
 type DescriptiveAnalyzer struct {
-	repo *database.Repository
-	db   *sql.DB
+	repo   *database.Repository
+	db     database.Querier
+	source string
 }
 
 func NewDescriptiveAnalyzer(repo *database.Repository) *DescriptiveAnalyzer {
 	return &DescriptiveAnalyzer{
 		repo: repo,
-		db:   database.GetDB(),
+		db:   database.GetQuerier(),
+	}
+}
+
+// WithSource returns a copy of the analyzer whose aggregate queries only
+// look at posts from the named source
+func (a *DescriptiveAnalyzer) WithSource(name string) *DescriptiveAnalyzer {
+	filtered := *a
+	filtered.source = name
+	return &filtered
+}
+
+// postsFrom returns the relation to select posts from, narrowed to one
+// source when it isn't empty. the subquery keeps the posts name so callers
+// can interpolate it where the table would go without renumbering params.
+func postsFrom(source string) string {
+	if source == "" {
+		return "posts"
 	}
+	return fmt.Sprintf("(SELECT * FROM posts WHERE source = %s) posts", pq.QuoteLiteral(source))
 }
 
 func (a *DescriptiveAnalyzer) BasicStatistics() (map[string]interface{}, error) {
-	return a.repo.GetBasicStats()
+	return a.repo.GetBasicStatsForSource(a.source)
 }
 
 type HourlyPattern struct {
-	Hour      int
-	PostCount int
-	AvgPoints float64
+	Hour      int     `json:"hour"`
+	PostCount int     `json:"post_count"`
+	AvgPoints float64 `json:"avg_points"`
 }
 
 func (a *DescriptiveAnalyzer) GetPostingPatterns() ([]HourlyPattern, error) {
-	query := `
+	query := fmt.Sprintf(`
 		SELECT EXTRACT(HOUR FROM post_time) as hour,
 		       COUNT(*) as count,
 		       AVG(points) as avg_points
-		FROM posts
+		FROM %s
+		WHERE post_time IS NOT NULL
 		GROUP BY hour
-		ORDER BY hour`
+		ORDER BY hour`, postsFrom(a.source))
 
 	rows, err := a.db.Query(query)
 	if err != nil {
@@ -57,24 +80,153 @@ func (a *DescriptiveAnalyzer) GetPostingPatterns() ([]HourlyPattern, error) {
 	return patterns, nil
 }
 
-type AuthorStats struct {
-	Author    string
+// GetActivityHeatmap returns post counts indexed by [day of week][hour],
+// with day 0 being Sunday as in postgres DOW
+func (a *DescriptiveAnalyzer) GetActivityHeatmap() ([7][24]int, error) {
+	var heatmap [7][24]int
+
+	query := fmt.Sprintf(`
+		SELECT EXTRACT(DOW FROM post_time)::int as dow,
+		       EXTRACT(HOUR FROM post_time)::int as hour,
+		       COUNT(*) as count
+		FROM %s
+		WHERE post_time IS NOT NULL
+		GROUP BY dow, hour`, postsFrom(a.source))
+
+	rows, err := a.db.Query(query)
+	if err != nil {
+		return heatmap, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var dow, hour, count int
+		if err := rows.Scan(&dow, &hour, &count); err != nil {
+			return heatmap, err
+		}
+		if dow >= 0 && dow < 7 && hour >= 0 && hour < 24 {
+			heatmap[dow][hour] = count
+		}
+	}
+
+	return heatmap, nil
+}
+
+type WeekdayStats struct {
+	Day       time.Weekday
 	PostCount int
 	AvgPoints float64
-	MaxPoints int
+}
+
+// GetDayOfWeekStats returns post counts and average points for every day
+// of the week, monday first. days without posts are included with zeros.
+func (a *DescriptiveAnalyzer) GetDayOfWeekStats() ([]WeekdayStats, error) {
+	query := fmt.Sprintf(`
+		SELECT EXTRACT(DOW FROM post_time)::int as dow,
+		       COUNT(*) as count,
+		       COALESCE(AVG(points), 0) as avg_points
+		FROM %s
+		WHERE post_time IS NOT NULL
+		GROUP BY dow`, postsFrom(a.source))
+
+	rows, err := a.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// postgres DOW numbers days like time.Weekday, sunday being 0
+	var byDay [7]WeekdayStats
+	for rows.Next() {
+		var dow int
+		var s WeekdayStats
+		if err := rows.Scan(&dow, &s.PostCount, &s.AvgPoints); err != nil {
+			return nil, err
+		}
+		if dow >= 0 && dow < 7 {
+			byDay[dow] = s
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	stats := make([]WeekdayStats, 0, 7)
+	for i := 1; i <= 7; i++ {
+		day := time.Weekday(i % 7)
+		s := byDay[day]
+		s.Day = day
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
+
+type TitleLengthBucket struct {
+	MinLen    int
+	MaxLen    int // zero for the open-ended last bucket
+	PostCount int
+	AvgPoints float64
+}
+
+// titles are bucketed in steps of titleBucketWidth characters, with
+// everything past titleBucketCount buckets folded into the last one
+const (
+	titleBucketWidth = 20
+	titleBucketCount = 6
+)
+
+// GetTitleLengthBuckets returns post counts and average points by title
+// length, shortest first. empty buckets are left out.
+func (a *DescriptiveAnalyzer) GetTitleLengthBuckets() ([]TitleLengthBucket, error) {
+	query := fmt.Sprintf(`
+		SELECT LEAST(GREATEST(LENGTH(title) - 1, 0) / $1, $2 - 1) as bucket,
+		       COUNT(*) as count,
+		       COALESCE(AVG(points), 0) as avg_points
+		FROM %s
+		GROUP BY bucket
+		ORDER BY bucket`, postsFrom(a.source))
+
+	rows, err := a.db.Query(query, titleBucketWidth, titleBucketCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var buckets []TitleLengthBucket
+	for rows.Next() {
+		var index int
+		var b TitleLengthBucket
+		if err := rows.Scan(&index, &b.PostCount, &b.AvgPoints); err != nil {
+			return nil, err
+		}
+		b.MinLen = index*titleBucketWidth + 1
+		if index < titleBucketCount-1 {
+			b.MaxLen = (index + 1) * titleBucketWidth
+		}
+		buckets = append(buckets, b)
+	}
+
+	return buckets, rows.Err()
+}
+
+type AuthorStats struct {
+	Author    string  `json:"author"`
+	PostCount int     `json:"post_count"`
+	AvgPoints float64 `json:"avg_points"`
+	MaxPoints int     `json:"max_points"`
 }
 
 func (a *DescriptiveAnalyzer) GetTopAuthors(minPosts int, limit int) ([]AuthorStats, error) {
-	query := `
+	query := fmt.Sprintf(`
 		SELECT author,
 		       COUNT(*) as post_count,
 		       AVG(points) as avg_points,
 		       MAX(points) as max_points
-		FROM posts
+		FROM %s
 		GROUP BY author
 		HAVING COUNT(*) >= $1
 		ORDER BY avg_points DESC
-		LIMIT $2`
+		LIMIT $2`, postsFrom(a.source))
 
 	rows, err := a.db.Query(query, minPosts, limit)
 	if err != nil {
@@ -95,8 +247,58 @@ func (a *DescriptiveAnalyzer) GetTopAuthors(minPosts int, limit int) ([]AuthorSt
 	return authors, nil
 }
 
+// AuthorConsistency measures how much an author's scores vary. CV is the
+// standard deviation over the mean, so authors with different typical
+// scores can be compared; a low CV means they reliably score near their
+// average, a high one usually means a single viral hit.
+type AuthorConsistency struct {
+	Author    string
+	PostCount int
+	AvgPoints float64
+	StdDev    float64
+	CV        float64
+}
+
+// GetAuthorConsistency returns every author with at least minPosts posts
+// and a positive average, most consistent first. a deviation needs two
+// posts, so minPosts below 2 is raised to 2.
+func (a *DescriptiveAnalyzer) GetAuthorConsistency(minPosts int) ([]AuthorConsistency, error) {
+	if minPosts < 2 {
+		minPosts = 2
+	}
+
+	query := fmt.Sprintf(`
+		SELECT author,
+		       COUNT(*) as post_count,
+		       AVG(points) as avg_points,
+		       STDDEV_SAMP(points) as stddev
+		FROM %s
+		WHERE author <> ''
+		GROUP BY author
+		HAVING COUNT(*) >= $1 AND AVG(points) > 0
+		ORDER BY STDDEV_SAMP(points) / AVG(points), post_count DESC`, postsFrom(a.source))
+
+	rows, err := a.db.Query(query, minPosts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var authors []AuthorConsistency
+	for rows.Next() {
+		var ac AuthorConsistency
+		if err := rows.Scan(&ac.Author, &ac.PostCount, &ac.AvgPoints, &ac.StdDev); err != nil {
+			return nil, err
+		}
+		ac.CV = ac.StdDev / ac.AvgPoints
+		authors = append(authors, ac)
+	}
+
+	return authors, rows.Err()
+}
+
 func (a *DescriptiveAnalyzer) GetTopPosts(limit int) ([]models.Post, error) {
-	return a.repo.GetTopPosts(limit)
+	return a.repo.GetTopPostsForSource(a.source, limit)
 }
 
 type DailyTrend struct {
@@ -112,10 +314,10 @@ func (a *DescriptiveAnalyzer) GetDailyTrends(days int) ([]DailyTrend, error) {
 		       COUNT(*) as posts,
 		       COALESCE(AVG(points), 0) as avg_points,
 		       COALESCE(AVG(comments_count), 0) as avg_comments
-		FROM posts
+		FROM %s
 		WHERE post_time > CURRENT_DATE - INTERVAL '%d days'
 		GROUP BY DATE(post_time)
-		ORDER BY date DESC`, days)
+		ORDER BY date DESC`, postsFrom(a.source), days)
 
 	rows, err := a.db.Query(query)
 	if err != nil {
@@ -147,16 +349,27 @@ type Distribution struct {
 }
 
 func (a *DescriptiveAnalyzer) GetPointsDistribution() (*Distribution, error) {
+	return a.getDistribution("points")
+}
+
+func (a *DescriptiveAnalyzer) GetCommentsDistribution() (*Distribution, error) {
+	return a.getDistribution("comments_count")
+}
+
+// getDistribution summarises a numeric posts column. both distributions are
+// taken over scored posts so their shapes describe the same population.
+// column is interpolated, so only pass constants.
+func (a *DescriptiveAnalyzer) getDistribution(column string) (*Distribution, error) {
 	dist := &Distribution{}
 
 	var stddev sql.NullFloat64
-	err := a.db.QueryRow(`
-		SELECT COALESCE(MIN(points), 0), 
-		       COALESCE(MAX(points), 0), 
-		       COALESCE(AVG(points), 0), 
-		       STDDEV(points)
-		FROM posts
-		WHERE points > 0`).Scan(&dist.Min, &dist.Max, &dist.Mean, &stddev)
+	err := a.db.QueryRow(fmt.Sprintf(`
+		SELECT COALESCE(MIN(%[1]s), 0), 
+		       COALESCE(MAX(%[1]s), 0), 
+		       COALESCE(AVG(%[1]s), 0), 
+		       STDDEV(%[1]s)
+		FROM %[2]s
+		WHERE points > 0`, column, postsFrom(a.source))).Scan(&dist.Min, &dist.Max, &dist.Mean, &stddev)
 	if err != nil {
 		return nil, err
 	}
@@ -165,16 +378,149 @@ func (a *DescriptiveAnalyzer) GetPointsDistribution() (*Distribution, error) {
 		dist.StdDev = stddev.Float64
 	}
 
-	err = a.db.QueryRow(`
+	var median, q1, q3 sql.NullFloat64
+	err = a.db.QueryRow(fmt.Sprintf(`
 		SELECT 
-			PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY points) as median,
-			PERCENTILE_CONT(0.25) WITHIN GROUP (ORDER BY points) as q1,
-			PERCENTILE_CONT(0.75) WITHIN GROUP (ORDER BY points) as q3
-		FROM posts
-		WHERE points > 0`).Scan(&dist.Median, &dist.Percentile25, &dist.Percentile75)
+			PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY %[1]s) as median,
+			PERCENTILE_CONT(0.25) WITHIN GROUP (ORDER BY %[1]s) as q1,
+			PERCENTILE_CONT(0.75) WITHIN GROUP (ORDER BY %[1]s) as q3
+		FROM %[2]s
+		WHERE points > 0`, column, postsFrom(a.source))).Scan(&median, &q1, &q3)
 	if err != nil {
 		return nil, err
 	}
+	dist.Median = median.Float64
+	dist.Percentile25 = q1.Float64
+	dist.Percentile75 = q3.Float64
 
 	return dist, nil
-}
\ No newline at end of file
+}
+
+// GetPercentiles computes the given percentiles (fractions in (0,1]) of a
+// whitelisted numeric field over scored posts, in the order requested
+func (a *DescriptiveAnalyzer) GetPercentiles(field string, fractions []float64) ([]float64, error) {
+	expr, err := database.ResolveNumericField(field)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []sql.NullFloat64
+	query := fmt.Sprintf(`
+		SELECT PERCENTILE_CONT($1::float8[]) WITHIN GROUP (ORDER BY %[1]s)
+		FROM %[2]s
+		WHERE points > 0 AND %[1]s IS NOT NULL`, expr, postsFrom(a.source))
+
+	err = a.db.QueryRow(query, pq.Array(fractions)).Scan(pq.Array(&values))
+	if err != nil {
+		return nil, err
+	}
+
+	// an empty table yields NULL for the whole array
+	if values == nil {
+		return nil, fmt.Errorf("no scored posts to compute percentiles from")
+	}
+
+	result := make([]float64, len(values))
+	for i, v := range values {
+		result[i] = v.Float64
+	}
+	return result, nil
+}
+
+// GetPointsGini measures how concentrated points are across posts:
+// 0 means every post scored the same, values near 1 mean a few posts
+// took almost everything. uses G = 2*sum(i*x_i)/(n*sum(x)) - (n+1)/n
+// over points sorted ascending.
+func (a *DescriptiveAnalyzer) GetPointsGini() (float64, error) {
+	var n int
+	var total, weighted float64
+	err := a.db.QueryRow(fmt.Sprintf(`
+		SELECT COUNT(*),
+		       COALESCE(SUM(points), 0),
+		       COALESCE(SUM(rn * points), 0)
+		FROM (
+			SELECT points, ROW_NUMBER() OVER (ORDER BY points) as rn
+			FROM %s
+			WHERE points >= 0
+		) ranked`, postsFrom(a.source))).Scan(&n, &total, &weighted)
+	if err != nil {
+		return 0, err
+	}
+
+	if n == 0 || total == 0 {
+		return 0, nil
+	}
+
+	return 2*weighted/(float64(n)*total) - float64(n+1)/float64(n), nil
+}
+
+type ResurfacedPost struct {
+	HnID         int
+	Title        string
+	FlatPoints   int
+	Points       int
+	FlatSince    time.Time
+	ResurfacedAt time.Time
+}
+
+// a post counts as resurfaced when its score sat unchanged for at least
+// resurfaceFlatPeriod across our history samples and then rose by at
+// least resurfaceMinJump points
+const (
+	resurfaceFlatPeriod = 6 * time.Hour
+	resurfaceMinJump    = 10
+	resurfaceLimit      = 20
+)
+
+// GetResurfacedPosts looks for the second-chance pattern in post_history:
+// a long plateau followed by a jump, most recent resurfacing first
+func (a *DescriptiveAnalyzer) GetResurfacedPosts() ([]ResurfacedPost, error) {
+	query := `
+		WITH samples AS (
+			SELECT post_id, points, recorded_at,
+			       LAG(points) OVER w as prev_points,
+			       LAG(recorded_at) OVER w as prev_at
+			FROM post_history
+			WINDOW w AS (PARTITION BY post_id ORDER BY recorded_at)
+		), changes AS (
+			SELECT post_id, points, recorded_at, prev_at
+			FROM samples
+			WHERE prev_points IS NULL OR points <> prev_points
+		), steps AS (
+			SELECT post_id, points, recorded_at, prev_at,
+			       LAG(points) OVER w as flat_points,
+			       LAG(recorded_at) OVER w as flat_since
+			FROM changes
+			WINDOW w AS (PARTITION BY post_id ORDER BY recorded_at)
+		), jumps AS (
+			SELECT DISTINCT ON (post_id) post_id, flat_points, points, flat_since, recorded_at
+			FROM steps
+			WHERE flat_since IS NOT NULL
+			  AND prev_at - flat_since >= $1 * INTERVAL '1 second'
+			  AND points - flat_points >= $2
+			ORDER BY post_id, recorded_at DESC
+		)
+		SELECT p.hn_id, p.title, j.flat_points, j.points, j.flat_since, j.recorded_at
+		FROM jumps j
+		JOIN posts p ON p.id = j.post_id
+		ORDER BY j.recorded_at DESC
+		LIMIT $3`
+
+	rows, err := a.db.Query(query, resurfaceFlatPeriod.Seconds(), resurfaceMinJump, resurfaceLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []ResurfacedPost
+	for rows.Next() {
+		var rp ResurfacedPost
+		err := rows.Scan(&rp.HnID, &rp.Title, &rp.FlatPoints, &rp.Points, &rp.FlatSince, &rp.ResurfacedAt)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, rp)
+	}
+
+	return posts, nil
+}
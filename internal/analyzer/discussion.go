@@ -0,0 +1,141 @@
+package analyzer
+
+import (
+	"fmt"
+
+	"github.com/dzmitry-papkou/scraper/internal/database"
+	"github.com/dzmitry-papkou/scraper/internal/models"
+)
+
+// discussion ratio is comments per point. a post needs a few points before
+// its ratio means anything, otherwise 3 comments on 1 point tops the list.
+const (
+	discussionMinPoints     = 10
+	discussionMinDomainPost = 3
+	discussionLimit         = 10
+)
+
+type DiscussionPost struct {
+	Post  models.Post
+	Ratio float64
+}
+
+type DomainDiscussion struct {
+	Domain    string
+	PostCount int
+	Ratio     float64
+}
+
+type DiscussionRatioStats struct {
+	// total comments over total points across eligible posts
+	OverallRatio float64
+	// mean and median of the per-post ratios
+	MeanRatio   float64
+	MedianRatio float64
+	PostCount   int
+	TopPosts    []DiscussionPost
+	TopDomains  []DomainDiscussion
+}
+
+// GetControversialPosts returns posts with at least minComments comments
+// and minRatio comments per point, highest ratio first. lots of comments on
+// a modest score usually means argument rather than agreement.
+func (a *DescriptiveAnalyzer) GetControversialPosts(minRatio float64, minComments, limit int) ([]DiscussionPost, error) {
+	rows, err := a.db.Query(fmt.Sprintf(`
+		SELECT id, hn_id, title, url, author, points, comments_count, post_time,
+		       comments_count::float8 / GREATEST(points, 1) as ratio
+		FROM %s
+		WHERE comments_count >= $1
+		  AND comments_count::float8 / GREATEST(points, 1) >= $2
+		ORDER BY ratio DESC, comments_count DESC
+		LIMIT $3`, postsFrom(a.source)), minComments, minRatio, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get controversial posts: %w", err)
+	}
+	defer rows.Close()
+
+	var posts []DiscussionPost
+	for rows.Next() {
+		var dp DiscussionPost
+		p := &dp.Post
+		err := rows.Scan(&p.ID, &p.HnID, &p.Title, &p.URL, &p.Author,
+			&p.Points, &p.CommentsCount, database.NullTime(&p.PostTime), &dp.Ratio)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, dp)
+	}
+
+	return posts, rows.Err()
+}
+
+// GetDiscussionRatioStats reports how much discussion posts draw relative to
+// their score. zero-point posts are excluded by the minimum points filter,
+// and NULLIF keeps the division safe regardless.
+func (a *DescriptiveAnalyzer) GetDiscussionRatioStats() (*DiscussionRatioStats, error) {
+	stats := &DiscussionRatioStats{}
+
+	err := a.db.QueryRow(fmt.Sprintf(`
+		SELECT COUNT(*),
+		       COALESCE(SUM(comments_count)::float8 / NULLIF(SUM(points), 0), 0),
+		       COALESCE(AVG(comments_count::float8 / NULLIF(points, 0)), 0),
+		       COALESCE(PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY comments_count::float8 / NULLIF(points, 0)), 0)
+		FROM %s
+		WHERE points >= $1`, postsFrom(a.source)), discussionMinPoints).Scan(&stats.PostCount, &stats.OverallRatio, &stats.MeanRatio, &stats.MedianRatio)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get discussion ratio: %w", err)
+	}
+
+	rows, err := a.db.Query(fmt.Sprintf(`
+		SELECT id, hn_id, title, url, author, points, comments_count, post_time,
+		       comments_count::float8 / NULLIF(points, 0) as ratio
+		FROM %s
+		WHERE points >= $1
+		ORDER BY ratio DESC, comments_count DESC
+		LIMIT $2`, postsFrom(a.source)), discussionMinPoints, discussionLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get discussion posts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var dp DiscussionPost
+		p := &dp.Post
+		err := rows.Scan(&p.ID, &p.HnID, &p.Title, &p.URL, &p.Author,
+			&p.Points, &p.CommentsCount, database.NullTime(&p.PostTime), &dp.Ratio)
+		if err != nil {
+			return nil, err
+		}
+		stats.TopPosts = append(stats.TopPosts, dp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	domainRows, err := a.db.Query(fmt.Sprintf(`
+		SELECT domain, COUNT(*), SUM(comments_count)::float8 / NULLIF(SUM(points), 0) as ratio
+		FROM (
+			SELECT %s as domain, points, comments_count
+			FROM %s
+			WHERE points >= $1
+		) linked
+		WHERE domain IS NOT NULL AND domain <> 'news.ycombinator.com'
+		GROUP BY domain
+		HAVING COUNT(*) >= $2
+		ORDER BY ratio DESC
+		LIMIT $3`, domainExpr, postsFrom(a.source)), discussionMinPoints, discussionMinDomainPost, discussionLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get discussion domains: %w", err)
+	}
+	defer domainRows.Close()
+
+	for domainRows.Next() {
+		var dd DomainDiscussion
+		if err := domainRows.Scan(&dd.Domain, &dd.PostCount, &dd.Ratio); err != nil {
+			return nil, err
+		}
+		stats.TopDomains = append(stats.TopDomains, dd)
+	}
+
+	return stats, domainRows.Err()
+}
@@ -0,0 +1,50 @@
+package analyzer
+
+import (
+	"math"
+	"testing"
+)
+
+func TestStudentTPValue(t *testing.T) {
+	// critical values from a two-tailed t table
+	tests := []struct {
+		t, df, want float64
+	}{
+		{2.228, 10, 0.05},
+		{-2.228, 10, 0.05},
+		{12.706, 1, 0.05},
+		{2.776, 4, 0.05},
+		{3.169, 10, 0.01},
+		{1.96, 1e6, 0.05},
+		{2.5, 3, 0.0877},
+		{0, 10, 1},
+	}
+	for _, tt := range tests {
+		if got := studentTPValue(tt.t, tt.df); math.Abs(got-tt.want) > 5e-4 {
+			t.Errorf("studentTPValue(%g, %g) = %.4f, want %.4f", tt.t, tt.df, got, tt.want)
+		}
+	}
+
+	if got := studentTPValue(1, 0); !math.IsNaN(got) {
+		t.Errorf("studentTPValue with df 0 = %g, want NaN", got)
+	}
+}
+
+func TestWelchTTestSignificanceFollowsPValue(t *testing.T) {
+	// t = 2.5 with 2 degrees of freedom clears the old |t| > 2 rule,
+	// but p is about 0.13
+	group := func(count int, mean, variance float64) *pointsSample {
+		s := &pointsSample{count: count, mean: mean}
+		s.variance.Valid, s.variance.Float64 = true, variance
+		return s
+	}
+	result := &TTestResult{Group1Name: "a", Group2Name: "b"}
+	welchTTest(result, group(2, 12.5, 1), group(2, 10, 1), 2)
+
+	if math.Abs(result.TStatistic-2.5) > 1e-9 {
+		t.Fatalf("TStatistic = %g, want 2.5", result.TStatistic)
+	}
+	if result.Significant {
+		t.Errorf("Significant with p = %.3f", result.PValue)
+	}
+}
@@ -2,24 +2,47 @@ package analyzer
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"math"
 
+	"github.com/dzmitry-papkou/scraper/internal/config"
 	"github.com/dzmitry-papkou/scraper/internal/database"
 )
 
 type InferentialAnalyzer struct {
-	repo *database.Repository
-	db   *sql.DB
+	repo          *database.Repository
+	db            database.Querier
+	minSampleSize int
+	source        string
 }
 
 func NewInferentialAnalyzer(repo *database.Repository) *InferentialAnalyzer {
 	return &InferentialAnalyzer{
-		repo: repo,
-		db:   database.GetDB(),
+		repo:          repo,
+		db:            database.GetQuerier(),
+		minSampleSize: config.Get().App.Analysis.MinSampleSize,
 	}
 }
 
+// WithSource returns a copy of the analyzer that only tests posts from the
+// named source
+func (a *InferentialAnalyzer) WithSource(name string) *InferentialAnalyzer {
+	filtered := *a
+	filtered.source = name
+	return &filtered
+}
+
+var ErrUnderPowered = errors.New("under-powered")
+
+// a variance needs at least two observations, whatever the config says
+func (a *InferentialAnalyzer) minSample() int {
+	if a.minSampleSize < 2 {
+		return 2
+	}
+	return a.minSampleSize
+}
+
 func (a *InferentialAnalyzer) CorrelationAnalysis() map[string]float64 {
 	results := make(map[string]float64)
 
@@ -38,21 +61,98 @@ func (a *InferentialAnalyzer) CorrelationAnalysis() map[string]float64 {
 	return results
 }
 
+// Correlation computes the correlation between two whitelisted numeric fields
+func (a *InferentialAnalyzer) Correlation(field1, field2 string) (float64, error) {
+	expr1, err := database.ResolveNumericField(field1)
+	if err != nil {
+		return 0, err
+	}
+	expr2, err := database.ResolveNumericField(field2)
+	if err != nil {
+		return 0, err
+	}
+	return a.calculateCorrelation(expr1, expr2)
+}
+
 func (a *InferentialAnalyzer) calculateCorrelation(field1, field2 string) (float64, error) {
 	var correlation sql.NullFloat64
+	var count int
+	// IS NOT NULL rather than > 0 so sunday (dow 0) and midnight (hour 0) count
 	query := fmt.Sprintf(`
-		SELECT CORR(%s::numeric, %s::numeric)
-		FROM posts
+		SELECT CORR(%s::numeric, %s::numeric), COUNT(*)
+		FROM %s
 		WHERE points > 0 AND %s IS NOT NULL AND %s IS NOT NULL`, 
-		field1, field2, field1, field2)
+		field1, field2, postsFrom(a.source), field1, field2)
 
-	err := a.db.QueryRow(query).Scan(&correlation)
-	if err != nil || !correlation.Valid {
+	err := a.db.QueryRow(query).Scan(&correlation, &count)
+	if err != nil {
 		return 0, err
 	}
+	if count < a.minSample() {
+		return 0, fmt.Errorf("%w: %d scored posts, need at least %d", ErrUnderPowered, count, a.minSample())
+	}
+	if !correlation.Valid {
+		return 0, nil
+	}
 	return correlation.Float64, nil
 }
 
+// RankCorrelation relates front-page position to attention. rank 1 is the
+// top slot, so position driving attention shows up as a negative value.
+type RankCorrelation struct {
+	Points   float64
+	Comments float64
+}
+
+// RankVsPoints correlates rank with points and with comments over posts
+// scraped from a ranked listing; unranked posts (rank NULL or 0) drop out
+func (a *InferentialAnalyzer) RankVsPoints() (*RankCorrelation, error) {
+	points, err := a.calculateCorrelation("NULLIF(rank, 0)", "points")
+	if err != nil {
+		return nil, fmt.Errorf("rank vs points: %w", err)
+	}
+	comments, err := a.calculateCorrelation("NULLIF(rank, 0)", "comments_count")
+	if err != nil {
+		return nil, fmt.Errorf("rank vs comments: %w", err)
+	}
+	return &RankCorrelation{Points: points, Comments: comments}, nil
+}
+
+var DefaultMatrixFields = []string{"points", "comments", "title_length", "hour", "dow"}
+
+// CorrelationMatrix returns the symmetric matrix of Pearson correlations
+// between the given whitelisted fields, indexed in the order given. only
+// the upper triangle is queried; the lower one is mirrored from it.
+func (a *InferentialAnalyzer) CorrelationMatrix(fields []string) ([][]float64, error) {
+	exprs := make([]string, len(fields))
+	for i, field := range fields {
+		expr, err := database.ResolveNumericField(field)
+		if err != nil {
+			return nil, err
+		}
+		exprs[i] = expr
+	}
+
+	matrix := make([][]float64, len(fields))
+	for i := range matrix {
+		matrix[i] = make([]float64, len(fields))
+		matrix[i][i] = 1
+	}
+
+	for i := 0; i < len(exprs); i++ {
+		for j := i + 1; j < len(exprs); j++ {
+			corr, err := a.calculateCorrelation(exprs[i], exprs[j])
+			if err != nil {
+				return nil, fmt.Errorf("%s vs %s: %w", fields[i], fields[j], err)
+			}
+			matrix[i][j] = corr
+			matrix[j][i] = corr
+		}
+	}
+
+	return matrix, nil
+}
+
 type TTestResult struct {
 	Group1Name    string
 	Group1Mean    float64
@@ -66,95 +166,120 @@ type TTestResult struct {
 	DegreesOfFreedom float64
 	PValue        float64
 	Significant   bool
+	UnderPowered  bool
 	Interpretation string
 }
 
-func (a *InferentialAnalyzer) WeekdayVsWeekendTTest() (*TTestResult, error) {
-	result := &TTestResult{
-		Group1Name: "Weekday",
-		Group2Name: "Weekend",
-	}
+// pointsSample holds the aggregates a Welch's t-test needs for one group
+type pointsSample struct {
+	count    int
+	mean     float64
+	stddev   sql.NullFloat64
+	variance sql.NullFloat64
+}
 
-	var weekdayStdDev, weekdayVariance sql.NullFloat64
-	err := a.db.QueryRow(`
+func (a *InferentialAnalyzer) samplePoints(where string, args ...interface{}) (*pointsSample, error) {
+	sample := &pointsSample{}
+	query := fmt.Sprintf(`
 		SELECT COUNT(*), 
 		       COALESCE(AVG(points), 0), 
 		       STDDEV(points), 
 		       VARIANCE(points)
-		FROM posts
-		WHERE EXTRACT(DOW FROM post_time) IN (1,2,3,4,5)
-		AND points > 0`).Scan(
-		&result.Group1Count,
-		&result.Group1Mean,
-		&weekdayStdDev,
-		&weekdayVariance,
+		FROM %s
+		WHERE %s
+		AND points > 0`, postsFrom(a.source), where)
+
+	err := a.db.QueryRow(query, args...).Scan(
+		&sample.count,
+		&sample.mean,
+		&sample.stddev,
+		&sample.variance,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("weekday query failed: %w", err)
+		return nil, err
 	}
+	return sample, nil
+}
+
+// welchTTest compares the points of two samples with unequal variances and
+// fills in the statistic, degrees of freedom and interpretation. groups
+// smaller than minSample are reported as under-powered instead.
+// significanceLevel is the two-tailed p-value below which a difference is
+// reported as significant
+const significanceLevel = 0.05
 
-	if weekdayStdDev.Valid {
-		result.Group1StdDev = weekdayStdDev.Float64
+func welchTTest(result *TTestResult, group1, group2 *pointsSample, minSample int) {
+	result.Group1Count = group1.count
+	result.Group1Mean = group1.mean
+	result.Group2Count = group2.count
+	result.Group2Mean = group2.mean
+
+	if group1.stddev.Valid {
+		result.Group1StdDev = group1.stddev.Float64
+	}
+	if group2.stddev.Valid {
+		result.Group2StdDev = group2.stddev.Float64
 	}
 
-	var weekendStdDev, weekendVariance sql.NullFloat64
-	err = a.db.QueryRow(`
-		SELECT COUNT(*), 
-		       COALESCE(AVG(points), 0), 
-		       STDDEV(points), 
-		       VARIANCE(points)
-		FROM posts
-		WHERE EXTRACT(DOW FROM post_time) IN (0,6)
-		AND points > 0`).Scan(
-		&result.Group2Count,
-		&result.Group2Mean,
-		&weekendStdDev,
-		&weekendVariance,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("weekend query failed: %w", err)
+	if result.Group1Count < minSample || result.Group2Count < minSample {
+		result.UnderPowered = true
+		result.Interpretation = fmt.Sprintf("Under-powered: need at least %d scored posts per group (%s: %d, %s: %d)",
+			minSample, result.Group1Name, result.Group1Count, result.Group2Name, result.Group2Count)
+		return
 	}
 
-	if weekendStdDev.Valid {
-		result.Group2StdDev = weekendStdDev.Float64
-	}
-
-	if result.Group1Count > 1 && result.Group2Count > 1 && 
-	   weekdayVariance.Valid && weekendVariance.Valid {
-		
-		meanDiff := result.Group1Mean - result.Group2Mean
-		se := math.Sqrt((weekdayVariance.Float64/float64(result.Group1Count)) + 
-		               (weekendVariance.Float64/float64(result.Group2Count)))
-		
-		if se > 0 {
-			result.TStatistic = meanDiff / se
-			
-			v1 := weekdayVariance.Float64 / float64(result.Group1Count)
-			v2 := weekendVariance.Float64 / float64(result.Group2Count)
-			result.DegreesOfFreedom = math.Pow(v1+v2, 2) / 
-				(math.Pow(v1, 2)/float64(result.Group1Count-1) + 
-				 math.Pow(v2, 2)/float64(result.Group2Count-1))
-			
-			criticalValue := 2.0
-			result.Significant = math.Abs(result.TStatistic) > criticalValue
-			
-			if result.Significant {
-				if meanDiff > 0 {
-					result.Interpretation = fmt.Sprintf("%s posts have significantly higher points than %s posts", 
-						result.Group1Name, result.Group2Name)
-				} else {
-					result.Interpretation = fmt.Sprintf("%s posts have significantly higher points than %s posts", 
-						result.Group2Name, result.Group1Name)
-				}
-			} else {
-				result.Interpretation = fmt.Sprintf("No significant difference between %s and %s posts", 
-					result.Group1Name, result.Group2Name)
-			}
+	if !group1.variance.Valid || !group2.variance.Valid {
+		result.Interpretation = "Insufficient data for statistical analysis"
+		return
+	}
+
+	meanDiff := result.Group1Mean - result.Group2Mean
+	v1 := group1.variance.Float64 / float64(result.Group1Count)
+	v2 := group2.variance.Float64 / float64(result.Group2Count)
+	se := math.Sqrt(v1 + v2)
+	if se == 0 {
+		return
+	}
+
+	result.TStatistic = meanDiff / se
+	result.DegreesOfFreedom = math.Pow(v1+v2, 2) /
+		(math.Pow(v1, 2)/float64(result.Group1Count-1) +
+			math.Pow(v2, 2)/float64(result.Group2Count-1))
+	result.PValue = studentTPValue(result.TStatistic, result.DegreesOfFreedom)
+
+	result.Significant = result.PValue < significanceLevel
+
+	if result.Significant {
+		if meanDiff > 0 {
+			result.Interpretation = fmt.Sprintf("%s posts have significantly higher points than %s posts", 
+				result.Group1Name, result.Group2Name)
+		} else {
+			result.Interpretation = fmt.Sprintf("%s posts have significantly higher points than %s posts", 
+				result.Group2Name, result.Group1Name)
 		}
 	} else {
-		result.Interpretation = "Insufficient data for statistical analysis"
+		result.Interpretation = fmt.Sprintf("No significant difference between %s and %s posts", 
+			result.Group1Name, result.Group2Name)
+	}
+}
+
+func (a *InferentialAnalyzer) WeekdayVsWeekendTTest() (*TTestResult, error) {
+	result := &TTestResult{
+		Group1Name: "Weekday",
+		Group2Name: "Weekend",
+	}
+
+	weekday, err := a.samplePoints("EXTRACT(DOW FROM post_time) IN (1,2,3,4,5)")
+	if err != nil {
+		return nil, fmt.Errorf("weekday query failed: %w", err)
+	}
+
+	weekend, err := a.samplePoints("EXTRACT(DOW FROM post_time) IN (0,6)")
+	if err != nil {
+		return nil, fmt.Errorf("weekend query failed: %w", err)
 	}
 
+	welchTTest(result, weekday, weekend, a.minSample())
 	return result, nil
 }
 
@@ -164,85 +289,65 @@ func (a *InferentialAnalyzer) MorningVsEveningTTest() (*TTestResult, error) {
 		Group2Name: "Evening (6PM-11PM)",
 	}
 
-	var morningStdDev, morningVariance sql.NullFloat64
-	err := a.db.QueryRow(`
-		SELECT COUNT(*), 
-		       COALESCE(AVG(points), 0), 
-		       STDDEV(points), 
-		       VARIANCE(points)
-		FROM posts
-		WHERE EXTRACT(HOUR FROM post_time) BETWEEN 6 AND 12
-		AND points > 0`).Scan(
-		&result.Group1Count,
-		&result.Group1Mean,
-		&morningStdDev,
-		&morningVariance,
-	)
+	morning, err := a.samplePoints("EXTRACT(HOUR FROM post_time) BETWEEN 6 AND 12")
 	if err != nil {
 		return nil, fmt.Errorf("morning query failed: %w", err)
 	}
 
-	if morningStdDev.Valid {
-		result.Group1StdDev = morningStdDev.Float64
+	evening, err := a.samplePoints("EXTRACT(HOUR FROM post_time) BETWEEN 18 AND 23")
+	if err != nil {
+		return nil, fmt.Errorf("evening query failed: %w", err)
 	}
 
-	var eveningStdDev, eveningVariance sql.NullFloat64
-	err = a.db.QueryRow(`
-		SELECT COUNT(*), 
-		       COALESCE(AVG(points), 0), 
-		       STDDEV(points), 
-		       VARIANCE(points)
-		FROM posts
-		WHERE EXTRACT(HOUR FROM post_time) BETWEEN 18 AND 23
-		AND points > 0`).Scan(
-		&result.Group2Count,
-		&result.Group2Mean,
-		&eveningStdDev,
-		&eveningVariance,
-	)
+	welchTTest(result, morning, evening, a.minSample())
+	return result, nil
+}
+
+// CompareSources runs a t-test on the points of posts from two scrapers
+func (a *InferentialAnalyzer) CompareSources(sourceA, sourceB string) (*TTestResult, error) {
+	result := &TTestResult{
+		Group1Name: sourceA,
+		Group2Name: sourceB,
+	}
+
+	groupA, err := a.samplePoints("source = $1", sourceA)
 	if err != nil {
-		return nil, fmt.Errorf("evening query failed: %w", err)
+		return nil, fmt.Errorf("%s query failed: %w", sourceA, err)
 	}
 
-	if eveningStdDev.Valid {
-		result.Group2StdDev = eveningStdDev.Float64
-	}
-
-	// t-test if valid data presented
-	if result.Group1Count > 1 && result.Group2Count > 1 && 
-	   morningVariance.Valid && eveningVariance.Valid {
-		
-		meanDiff := result.Group1Mean - result.Group2Mean
-		se := math.Sqrt((morningVariance.Float64/float64(result.Group1Count)) + 
-		               (eveningVariance.Float64/float64(result.Group2Count)))
-		
-		if se > 0 {
-			result.TStatistic = meanDiff / se
-			
-			// degrees of freedom
-			v1 := morningVariance.Float64 / float64(result.Group1Count)
-			v2 := eveningVariance.Float64 / float64(result.Group2Count)
-			result.DegreesOfFreedom = math.Pow(v1+v2, 2) / 
-				(math.Pow(v1, 2)/float64(result.Group1Count-1) + 
-				 math.Pow(v2, 2)/float64(result.Group2Count-1))
-			
-			// significance
-			criticalValue := 2.0
-			result.Significant = math.Abs(result.TStatistic) > criticalValue
-			
-			if result.Significant {
-				if meanDiff > 0 {
-					result.Interpretation = "Morning posts receive significantly more points than evening posts"
-				} else {
-					result.Interpretation = "Evening posts receive significantly more points than morning posts"
-				}
-			} else {
-				result.Interpretation = "No significant difference between morning and evening posts"
-			}
-		}
-	} else {
-		result.Interpretation = "Insufficient data for statistical analysis"
+	groupB, err := a.samplePoints("source = $1", sourceB)
+	if err != nil {
+		return nil, fmt.Errorf("%s query failed: %w", sourceB, err)
+	}
+
+	welchTTest(result, groupA, groupB, a.minSample())
+
+	return result, nil
+}
+
+// CompareWindows runs a t-test on the points of posts from the most recent
+// recentDays against the priorDays immediately before them
+func (a *InferentialAnalyzer) CompareWindows(recentDays, priorDays int) (*TTestResult, error) {
+	if recentDays <= 0 || priorDays <= 0 {
+		return nil, fmt.Errorf("window lengths must be positive, got %d and %d", recentDays, priorDays)
+	}
+
+	result := &TTestResult{
+		Group1Name: fmt.Sprintf("Last %d days", recentDays),
+		Group2Name: fmt.Sprintf("Previous %d days", priorDays),
 	}
 
+	recent, err := a.samplePoints("post_time > CURRENT_TIMESTAMP - $1 * INTERVAL '1 day'", recentDays)
+	if err != nil {
+		return nil, fmt.Errorf("recent window query failed: %w", err)
+	}
+
+	prior, err := a.samplePoints(`post_time <= CURRENT_TIMESTAMP - $1 * INTERVAL '1 day'
+		AND post_time > CURRENT_TIMESTAMP - ($1 + $2) * INTERVAL '1 day'`, recentDays, priorDays)
+	if err != nil {
+		return nil, fmt.Errorf("prior window query failed: %w", err)
+	}
+
+	welchTTest(result, recent, prior, a.minSample())
 	return result, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,152 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/dzmitry-papkou/scraper/internal/database"
+	"github.com/dzmitry-papkou/scraper/internal/models"
+)
+
+// TitleCluster is a set of posts whose titles are near-duplicates of each
+// other, best scored first
+type TitleCluster struct {
+	Posts []models.Post
+	// lowest similarity among the matched pairs in the cluster
+	Similarity float64
+}
+
+// titles with fewer distinct words than this match too easily to compare
+const similarMinTokens = 3
+
+// words that carry no topic and would otherwise link unrelated titles
+var titleStopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "and": true, "or": true, "of": true,
+	"to": true, "in": true, "on": true, "for": true, "with": true, "is": true,
+	"are": true, "by": true, "at": true, "from": true, "as": true, "it": true,
+	"its": true, "how": true, "why": true, "what": true, "your": true, "you": true,
+	"we": true, "i": true, "my": true, "this": true, "that": true, "be": true,
+	"show": true, "ask": true, "tell": true, "launch": true, "hn": true,
+}
+
+// titleTokens lowercases a title and returns its distinct words, minus stop
+// words and punctuation
+func titleTokens(title string) map[string]bool {
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	tokens := make(map[string]bool, len(words))
+	for _, word := range words {
+		if !titleStopWords[word] {
+			tokens[word] = true
+		}
+	}
+	return tokens
+}
+
+// GetSimilarTitles clusters posts whose titles have a token Jaccard
+// similarity of at least threshold. comparison happens in Go so it works
+// without pg_trgm; an inverted index keeps it to pairs sharing a word.
+func (a *DescriptiveAnalyzer) GetSimilarTitles(threshold float64) ([]TitleCluster, error) {
+	if threshold <= 0 || threshold > 1 {
+		return nil, fmt.Errorf("threshold must be in (0, 1], got %g", threshold)
+	}
+
+	rows, err := a.db.Query(fmt.Sprintf(`
+		SELECT id, hn_id, title, COALESCE(url, ''), author, points, comments_count, post_time, scraped_at
+		FROM %s
+		ORDER BY hn_id`, postsFrom(a.source)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []models.Post
+	var tokens []map[string]bool
+	for rows.Next() {
+		var p models.Post
+		err := rows.Scan(&p.ID, &p.HnID, &p.Title, &p.URL, &p.Author,
+			&p.Points, &p.CommentsCount, database.NullTime(&p.PostTime), &p.ScrapedAt)
+		if err != nil {
+			return nil, err
+		}
+		t := titleTokens(p.Title)
+		if len(t) < similarMinTokens {
+			continue
+		}
+		posts = append(posts, p)
+		tokens = append(tokens, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// union-find over posts, tracking the weakest link of each cluster
+	parent := make([]int, len(posts))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	weakest := make(map[int]float64)
+
+	index := make(map[string][]int)
+	for i, t := range tokens {
+		shared := make(map[int]int)
+		for word := range t {
+			for _, j := range index[word] {
+				shared[j]++
+			}
+			index[word] = append(index[word], i)
+		}
+
+		for j, n := range shared {
+			similarity := float64(n) / float64(len(t)+len(tokens[j])-n)
+			if similarity < threshold {
+				continue
+			}
+			ri, rj := find(i), find(j)
+			low := similarity
+			for _, root := range []int{ri, rj} {
+				if w, ok := weakest[root]; ok && w < low {
+					low = w
+				}
+			}
+			if ri != rj {
+				parent[ri] = rj
+				delete(weakest, ri)
+			}
+			weakest[rj] = low
+		}
+	}
+
+	members := make(map[int][]models.Post)
+	for i := range posts {
+		root := find(i)
+		members[root] = append(members[root], posts[i])
+	}
+
+	var clusters []TitleCluster
+	for root, group := range members {
+		if len(group) < 2 {
+			continue
+		}
+		sort.Slice(group, func(i, j int) bool { return group[i].Points > group[j].Points })
+		clusters = append(clusters, TitleCluster{Posts: group, Similarity: weakest[root]})
+	}
+
+	sort.Slice(clusters, func(i, j int) bool {
+		if len(clusters[i].Posts) != len(clusters[j].Posts) {
+			return len(clusters[i].Posts) > len(clusters[j].Posts)
+		}
+		return clusters[i].Posts[0].Points > clusters[j].Posts[0].Points
+	})
+	return clusters, nil
+}
@@ -0,0 +1,193 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/dzmitry-papkou/scraper/internal/models"
+)
+
+// StatsSummary holds the headline aggregates shown by stats, so they can be
+// cached in stats_summary instead of re-scanning posts on every call
+type StatsSummary struct {
+	TotalPosts     int             `json:"total_posts"`
+	UniqueAuthors  int             `json:"unique_authors"`
+	AvgPoints      float64         `json:"avg_points"`
+	AvgComments    float64         `json:"avg_comments"`
+	MaxPoints      int             `json:"max_points"`
+	MaxComments    int             `json:"max_comments"`
+	TopPosts       []models.Post   `json:"top_posts"`
+	TopAuthors     []AuthorStats   `json:"top_authors"`
+	TopDomains     []DomainCount   `json:"top_domains"`
+	HourlyPatterns []HourlyPattern `json:"hourly_patterns"`
+
+	// format of a stored summary; zero for one computed live
+	Version int `json:"version,omitempty"`
+	// when the summary was stored; zero for one computed live
+	ComputedAt time.Time `json:"-"`
+}
+
+// summaryVersion is stored with each summary. version 2 gave the list
+// elements snake_case keys; older summaries carry the Go field names.
+const summaryVersion = 2
+
+// legacySummaryLists decodes the lists of a summary stored before version
+// 2. tags don't matter in conversions, so each element converts straight
+// to the current type.
+type legacySummaryLists struct {
+	TopAuthors []struct {
+		Author    string
+		PostCount int
+		AvgPoints float64
+		MaxPoints int
+	} `json:"top_authors"`
+	TopDomains []struct {
+		Domain    string
+		PostCount int
+		AvgPoints float64
+	} `json:"top_domains"`
+	HourlyPatterns []struct {
+		Hour      int
+		PostCount int
+		AvgPoints float64
+	} `json:"hourly_patterns"`
+}
+
+type DomainCount struct {
+	Domain    string  `json:"domain"`
+	PostCount int     `json:"post_count"`
+	AvgPoints float64 `json:"avg_points"`
+}
+
+const summaryTopLimit = 5
+
+// ComputeSummary runs the stats queries against posts
+func (a *DescriptiveAnalyzer) ComputeSummary(minAuthorPosts int) (*StatsSummary, error) {
+	stats, err := a.BasicStatistics()
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &StatsSummary{
+		TotalPosts:    stats["total_posts"].(int),
+		UniqueAuthors: stats["unique_authors"].(int),
+		AvgPoints:     stats["avg_points"].(float64),
+		AvgComments:   stats["avg_comments"].(float64),
+		MaxPoints:     stats["max_points"].(int),
+		MaxComments:   stats["max_comments"].(int),
+	}
+
+	if summary.TopPosts, err = a.GetTopPosts(summaryTopLimit); err != nil {
+		return nil, fmt.Errorf("failed to get top posts: %w", err)
+	}
+	if summary.TopAuthors, err = a.GetTopAuthors(minAuthorPosts, summaryTopLimit); err != nil {
+		return nil, fmt.Errorf("failed to get top authors: %w", err)
+	}
+	if summary.TopDomains, err = a.GetTopDomains(summaryTopLimit); err != nil {
+		return nil, fmt.Errorf("failed to get top domains: %w", err)
+	}
+	if summary.HourlyPatterns, err = a.GetPostingPatterns(); err != nil {
+		return nil, fmt.Errorf("failed to get posting patterns: %w", err)
+	}
+
+	return summary, nil
+}
+
+// Summarize computes the summary and stores it in stats_summary
+func (a *DescriptiveAnalyzer) Summarize(minAuthorPosts int) (*StatsSummary, error) {
+	summary, err := a.ComputeSummary(minAuthorPosts)
+	if err != nil {
+		return nil, err
+	}
+
+	summary.Version = summaryVersion
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode summary: %w", err)
+	}
+	if summary.ComputedAt, err = a.repo.SaveStatsSummary(data); err != nil {
+		return nil, fmt.Errorf("failed to save summary: %w", err)
+	}
+	return summary, nil
+}
+
+// CachedSummary returns the last stored summary, or nil if there is none
+func (a *DescriptiveAnalyzer) CachedSummary() (*StatsSummary, error) {
+	data, computedAt, err := a.repo.GetLatestStatsSummary()
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	summary, err := decodeSummary(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode summary: %w", err)
+	}
+	summary.ComputedAt = computedAt
+	return summary, nil
+}
+
+// decodeSummary reads a stored summary of any version
+func decodeSummary(data []byte) (*StatsSummary, error) {
+	summary := &StatsSummary{}
+	if err := json.Unmarshal(data, summary); err != nil {
+		return nil, err
+	}
+	if summary.Version >= summaryVersion {
+		return summary, nil
+	}
+
+	var legacy legacySummaryLists
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return nil, err
+	}
+	summary.TopAuthors = make([]AuthorStats, len(legacy.TopAuthors))
+	for i, author := range legacy.TopAuthors {
+		summary.TopAuthors[i] = AuthorStats(author)
+	}
+	summary.TopDomains = make([]DomainCount, len(legacy.TopDomains))
+	for i, domain := range legacy.TopDomains {
+		summary.TopDomains[i] = DomainCount(domain)
+	}
+	summary.HourlyPatterns = make([]HourlyPattern, len(legacy.HourlyPatterns))
+	for i, pattern := range legacy.HourlyPatterns {
+		summary.HourlyPatterns[i] = HourlyPattern(pattern)
+	}
+	summary.Version = summaryVersion
+	return summary, nil
+}
+
+// domainExpr extracts the linked host without a leading www. in SQL, so it
+// also covers rows stored before the domain processor was enabled
+const domainExpr = `LOWER(SUBSTRING(url FROM '^[A-Za-z]+://(?:www\.)?([^/:?#]+)'))`
+
+// GetTopDomains counts linked posts by host, ignoring a leading www.
+func (a *DescriptiveAnalyzer) GetTopDomains(limit int) ([]DomainCount, error) {
+	query := fmt.Sprintf(`
+		SELECT domain, COUNT(*) as post_count, AVG(points) as avg_points
+		FROM (
+			SELECT %s as domain, points
+			FROM %s
+		) linked
+		WHERE domain IS NOT NULL AND domain <> 'news.ycombinator.com'
+		GROUP BY domain
+		ORDER BY post_count DESC, avg_points DESC
+		LIMIT $1`, domainExpr, postsFrom(a.source))
+
+	rows, err := a.db.Query(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var domains []DomainCount
+	for rows.Next() {
+		var d DomainCount
+		if err := rows.Scan(&d.Domain, &d.PostCount, &d.AvgPoints); err != nil {
+			return nil, err
+		}
+		domains = append(domains, d)
+	}
+
+	return domains, rows.Err()
+}
@@ -0,0 +1,43 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDecodeSummary(t *testing.T) {
+	legacy := `{"total_posts":3,
+		"top_authors":[{"Author":"pg","PostCount":2,"AvgPoints":10.5,"MaxPoints":12}],
+		"top_domains":[{"Domain":"example.com","PostCount":2,"AvgPoints":8}],
+		"hourly_patterns":[{"Hour":14,"PostCount":3,"AvgPoints":9}]}`
+
+	current, err := json.Marshal(&StatsSummary{
+		TotalPosts:     3,
+		TopAuthors:     []AuthorStats{{Author: "pg", PostCount: 2, AvgPoints: 10.5, MaxPoints: 12}},
+		TopDomains:     []DomainCount{{Domain: "example.com", PostCount: 2, AvgPoints: 8}},
+		HourlyPatterns: []HourlyPattern{{Hour: 14, PostCount: 3, AvgPoints: 9}},
+		Version:        summaryVersion,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, data := range map[string]string{"legacy": legacy, "current": string(current)} {
+		summary, err := decodeSummary([]byte(data))
+		if err != nil {
+			t.Fatalf("%s: decodeSummary: %v", name, err)
+		}
+		if summary.TotalPosts != 3 {
+			t.Errorf("%s: TotalPosts = %d, want 3", name, summary.TotalPosts)
+		}
+		if len(summary.TopAuthors) != 1 || summary.TopAuthors[0] != (AuthorStats{"pg", 2, 10.5, 12}) {
+			t.Errorf("%s: TopAuthors = %+v", name, summary.TopAuthors)
+		}
+		if len(summary.TopDomains) != 1 || summary.TopDomains[0] != (DomainCount{"example.com", 2, 8}) {
+			t.Errorf("%s: TopDomains = %+v", name, summary.TopDomains)
+		}
+		if len(summary.HourlyPatterns) != 1 || summary.HourlyPatterns[0] != (HourlyPattern{14, 3, 9}) {
+			t.Errorf("%s: HourlyPatterns = %+v", name, summary.HourlyPatterns)
+		}
+	}
+}
@@ -1,8 +1,15 @@
 package cli
 
 import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"os"
+	"sort"
 
 	"strconv"
 	"strings"
@@ -11,8 +18,10 @@ import (
 	"github.com/dzmitry-papkou/scraper/internal/analyzer"
 	"github.com/dzmitry-papkou/scraper/internal/config"
 	"github.com/dzmitry-papkou/scraper/internal/database"
+	"github.com/dzmitry-papkou/scraper/internal/models"
 	"github.com/dzmitry-papkou/scraper/internal/scraper"
 	"github.com/fatih/color"
+	"gopkg.in/yaml.v3"
 )
 
 type Commander struct {
@@ -23,8 +32,12 @@ type Commander struct {
 	inferentialAnalyzer *analyzer.InferentialAnalyzer
 	scheduler           *scraper.MultiScheduler
 	config              *config.Config
+	// stdin, shared with the interactive loop so prompts inside commands
+	// don't lose input buffered by a second reader
+	input               *bufio.Scanner
 	
-	// color
+	// theme colors, named after their defaults: success, error,
+	// warning, info and header roles from cli.colors
 	green  func(a ...interface{}) string
 	red    func(a ...interface{}) string
 	yellow func(a ...interface{}) string
@@ -35,11 +48,13 @@ type Commander struct {
 func NewCommanderWithConfig(repo *database.Repository, scraperName string, cfg *config.Config) (*Commander, error) {
 	scraperInstance, err := scraper.NewGenericScraper(repo, scraperName)
 	if err != nil {
-		scraperInstance = scraper.New(repo)
+		if scraperInstance, err = scraper.New(repo); err != nil {
+			return nil, err
+		}
 		scraperName = "hackernews"
 	}
 	
-	return &Commander{
+	commander := &Commander{
 		repo:               repo,
 		currentScraper:     scraperInstance,
 		currentScraperName: scraperName,
@@ -47,12 +62,57 @@ func NewCommanderWithConfig(repo *database.Repository, scraperName string, cfg *
 		inferentialAnalyzer: analyzer.NewInferentialAnalyzer(repo),
 		scheduler:          scraper.NewMultiScheduler(repo),
 		config:             cfg,
-		green:              color.New(color.FgGreen).SprintFunc(),
-		red:                color.New(color.FgRed).SprintFunc(),
-		yellow:             color.New(color.FgYellow).SprintFunc(),
-		cyan:               color.New(color.FgCyan).SprintFunc(),
-		blue:               color.New(color.FgBlue).SprintFunc(),
-	}, nil
+		input:              bufio.NewScanner(os.Stdin),
+		green:              themeColor(cfg, "success", color.FgGreen),
+		red:                themeColor(cfg, "error", color.FgRed),
+		yellow:             themeColor(cfg, "warning", color.FgYellow),
+		cyan:               themeColor(cfg, "info", color.FgCyan),
+		blue:               themeColor(cfg, "header", color.FgBlue),
+	}
+
+	go commander.renderSchedulerEvents()
+
+	return commander, nil
+}
+
+func (c *Commander) prompt() string {
+	if c.config.App.CLI.Prompt == "" {
+		return "➜"
+	}
+	return c.config.App.CLI.Prompt
+}
+
+// renderSchedulerEvents prints auto-scrape results above the prompt:
+// clear the current prompt line, print the event, then redraw the prompt
+func (c *Commander) renderSchedulerEvents() {
+	for event := range c.scheduler.Events() {
+		fmt.Print("\r\033[K")
+		if event.Err != nil {
+			fmt.Printf("%s [%s] Auto-scrape error for %s: %v\n",
+				c.red("✗"), event.Time.Format("15:04:05"), event.Scraper, event.Err)
+		} else {
+			fmt.Printf("%s [%s] Auto-scraped %d posts from %s\n",
+				c.green("✓"), event.Time.Format("15:04:05"), event.Count, event.Scraper)
+		}
+		for _, alert := range event.Alerts {
+			c.printPointsAlert(alert)
+		}
+		fmt.Print(c.yellow(c.prompt() + " "))
+	}
+}
+
+// themeColor builds the print func for a role from cli.colors, falling
+// back to the built-in color when the role isn't configured
+func themeColor(cfg *config.Config, role string, fallback color.Attribute) func(a ...interface{}) string {
+	attr := fallback
+	if cfg != nil {
+		if name, ok := cfg.App.CLI.Colors[role]; ok {
+			if configured, err := config.ColorAttribute(name); err == nil {
+				attr = configured
+			}
+		}
+	}
+	return color.New(attr).SprintFunc()
 }
 
 func NewCommander(repo *database.Repository) *Commander {
@@ -62,6 +122,35 @@ func NewCommander(repo *database.Repository) *Commander {
 	return commander
 }
 
+// SetInput makes commands that ask for confirmation read their answer from
+// scanner, which must be the one the interactive loop reads commands from
+func (c *Commander) SetInput(scanner *bufio.Scanner) {
+	c.input = scanner
+}
+
+// readAnswer reads one line of input, "" at the end of it
+func (c *Commander) readAnswer() string {
+	if !c.input.Scan() {
+		return ""
+	}
+	return strings.TrimSpace(c.input.Text())
+}
+
+// RunOnce runs one of the commands behind the -scrape, -analyze and -export
+// flags and returns its error, which ExecuteCommand only prints
+func (c *Commander) RunOnce(command string) error {
+	switch command {
+	case "scrape":
+		return c.scrapeOnce()
+	case "analyze":
+		return c.runAnalysis("")
+	case "export":
+		return c.exportData(nil)
+	default:
+		return fmt.Errorf("%s can't be run as a one-shot command", command)
+	}
+}
+
 func (c *Commander) ExecuteCommand(command string, args []string) {
 	switch command {
 	case "help", "h":
@@ -69,19 +158,92 @@ func (c *Commander) ExecuteCommand(command string, args []string) {
 	case "scrape", "s":
 		c.scrapeOnce()
 	case "scrape-all", "sall":
-    	c.scrapeAll()
+		var maxDuration time.Duration
+		resume := false
+		for _, arg := range args {
+			if arg == "--resume" {
+				resume = true
+				continue
+			}
+			d, err := time.ParseDuration(arg)
+			if err != nil || d <= 0 {
+				fmt.Printf("%s Invalid time limit: %s (e.g. 5m)\n", c.red("✗"), arg)
+				return
+			}
+			maxDuration = d
+		}
+		c.scrapeAll(maxDuration, resume)
 	case "scrape-new", "snew":
   		 c.scrapeNew()
+	case "catchup":
+		c.catchUp()
+	case "refresh-front":
+		c.refreshFront()
+	case "scrape-dates":
+		if len(args) < 1 {
+			fmt.Printf("%s Usage: scrape-dates <from> [to] (dates as YYYY-MM-DD)\n", c.red("✗"))
+			return
+		}
+		from, err := time.Parse("2006-01-02", args[0])
+		if err != nil {
+			fmt.Printf("%s Invalid date: %s (expected YYYY-MM-DD)\n", c.red("✗"), args[0])
+			return
+		}
+		to := from
+		if len(args) > 1 {
+			if to, err = time.Parse("2006-01-02", args[1]); err != nil {
+				fmt.Printf("%s Invalid date: %s (expected YYYY-MM-DD)\n", c.red("✗"), args[1])
+				return
+			}
+		}
+		c.scrapeDates(from, to)
+	case "refresh":
+		limit := 30
+		if len(args) > 0 {
+			if n, err := strconv.Atoi(args[0]); err == nil {
+				limit = n
+			}
+		}
+		c.refreshScores(limit)
+	case "benchmark", "bench":
+		pages := 1
+		if len(args) > 0 {
+			if n, err := strconv.Atoi(args[0]); err == nil && n > 0 {
+				pages = n
+			}
+		}
+		c.benchmark(pages)
 	case "scrape-history", "history":
     	c.showScrapingHistory()
 	case "start":
-		c.startAutoScraping()
+		c.startAutoScraping(args)
 	case "stop":
-		c.stopAutoScraping()
+		c.stopAutoScraping(args)
 	case "status":
 		c.showStatus()
+	case "schedule":
+		c.showSchedule()
 	case "stats":
-		c.showStatistics()
+		cached := false
+		format := "table"
+		for i := 0; i < len(args); i++ {
+			switch {
+			case args[i] == "--cached":
+				cached = true
+			case args[i] == "--format" && i+1 < len(args):
+				format = args[i+1]
+				i++
+			case strings.HasPrefix(args[i], "--format="):
+				format = strings.TrimPrefix(args[i], "--format=")
+			}
+		}
+		if format != "table" && format != "json" && format != "csv" {
+			fmt.Printf("%s Unknown format %q (expected table, json or csv)\n", c.red("✗"), format)
+			return
+		}
+		c.showStatistics(cached, format)
+	case "summarize":
+		c.summarize()
 	case "show":
 		limit := 10
 		if len(args) > 0 {
@@ -90,10 +252,319 @@ func (c *Commander) ExecuteCommand(command string, args []string) {
 			}
 		}
 		c.showRecentPosts(limit)
+	case "today":
+		c.showTodaysPosts()
 	case "analyze", "analyse", "a":
-		c.runAnalysis()
+		source := ""
+		for i := 0; i < len(args); i++ {
+			if args[i] != "--source" {
+				fmt.Printf("%s Unknown argument %q. Usage: analyze [--source name]\n", c.red("✗"), args[i])
+				return
+			}
+			if i+1 >= len(args) || args[i+1] == "" {
+				fmt.Printf("%s --source needs a value. Usage: analyze [--source name]\n", c.red("✗"))
+				return
+			}
+			source = args[i+1]
+			i++
+		}
+		if source != "" {
+			if err := c.checkSource(source); err != nil {
+				fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+				return
+			}
+		}
+		c.runAnalysis(source)
+	case "title-changes", "retitled":
+		limit := 10
+		if len(args) > 0 {
+			if n, err := strconv.Atoi(args[0]); err == nil {
+				limit = n
+			}
+		}
+		c.showTitleChanges(limit)
+	case "tracked":
+		limit := 10
+		if len(args) > 0 {
+			if n, err := strconv.Atoi(args[0]); err == nil {
+				limit = n
+			}
+		}
+		c.showTracked(limit)
+	case "fastest":
+		threshold := 100
+		if len(args) > 0 {
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n <= 0 {
+				fmt.Printf("%s Invalid points threshold: %s\n", c.red("✗"), args[0])
+				return
+			}
+			threshold = n
+		}
+		limit := 10
+		if len(args) > 1 {
+			if n, err := strconv.Atoi(args[1]); err == nil && n > 0 {
+				limit = n
+			}
+		}
+		c.showFastest(threshold, limit)
+	case "untracked":
+		limit := 20
+		if len(args) > 0 {
+			if n, err := strconv.Atoi(args[0]); err == nil && n > 0 {
+				limit = n
+			}
+		}
+		c.showUntracked(limit)
+	case "concentration", "gini":
+		c.showConcentration()
+	case "percentiles", "pct":
+		if len(args) != 2 {
+			fmt.Printf("%s Usage: percentiles <field> <p1,p2,...> (e.g. percentiles points 10,50,90,99)\n", c.red("✗"))
+			fmt.Printf("Fields: %s\n", strings.Join(database.NumericFieldNames(), ", "))
+			return
+		}
+		c.showPercentiles(args[0], args[1])
+	case "distribution", "dist":
+		field := "points"
+		if len(args) > 0 {
+			field = args[0]
+		}
+		c.showDistribution(field)
+	case "compare":
+		if len(args) != 2 {
+			fmt.Printf("%s Usage: compare <sourceA> <sourceB>\n", c.red("✗"))
+			return
+		}
+		c.compareSources(args[0], args[1])
+	case "compare-windows":
+		if len(args) != 2 {
+			fmt.Printf("%s Usage: compare-windows <daysA> <daysB>\n", c.red("✗"))
+			return
+		}
+		recent, err1 := strconv.Atoi(args[0])
+		prior, err2 := strconv.Atoi(args[1])
+		if err1 != nil || err2 != nil || recent <= 0 || prior <= 0 {
+			fmt.Printf("%s Window lengths must be positive numbers of days\n", c.red("✗"))
+			return
+		}
+		c.compareWindows(recent, prior)
+	case "discussion":
+		c.showDiscussion()
+	case "controversial":
+		limit := 20
+		if len(args) > 0 {
+			if n, err := strconv.Atoi(args[0]); err == nil && n > 0 {
+				limit = n
+			}
+		}
+		c.showControversial(limit)
+	case "author-consistency", "consistency":
+		limit := 10
+		if len(args) > 0 {
+			if n, err := strconv.Atoi(args[0]); err == nil && n > 0 {
+				limit = n
+			}
+		}
+		c.showAuthorConsistency(limit)
+	case "weekdays", "dow":
+		c.showWeekdays()
+	case "titlelen":
+		c.showTitleLengths()
+	case "heatmap":
+		c.showHeatmap()
+	case "resurfaced":
+		c.showResurfaced()
+	case "correlate", "corr":
+		if len(args) != 2 {
+			fmt.Printf("%s Usage: correlate <field1> <field2>\n", c.red("✗"))
+			fmt.Printf("Fields: %s\n", strings.Join(database.NumericFieldNames(), ", "))
+			return
+		}
+		c.showCorrelation(args[0], args[1])
+	case "matrix":
+		fields := analyzer.DefaultMatrixFields
+		if len(args) > 0 {
+			fields = args
+		}
+		c.showCorrelationMatrix(fields)
 	case "export", "e":
-		c.exportData()
+		c.exportData(args)
+	case "export-history":
+		if len(args) != 1 {
+			fmt.Printf("%s Usage: export-history <hn_id>\n", c.red("✗"))
+			return
+		}
+		hnID, err := strconv.Atoi(args[0])
+		if err != nil {
+			fmt.Printf("%s Invalid post ID: %s\n", c.red("✗"), args[0])
+			return
+		}
+		c.exportPostHistory(hnID)
+	case "backup":
+		withHistory := false
+		var path string
+		for _, arg := range args {
+			if arg == "--history" {
+				withHistory = true
+				continue
+			}
+			path = arg
+		}
+		if path == "" {
+			fmt.Printf("%s Usage: backup <file.jsonl> [--history]\n", c.red("✗"))
+			return
+		}
+		c.backup(path, withHistory)
+	case "restore":
+		if len(args) != 1 {
+			fmt.Printf("%s Usage: restore <file.jsonl>\n", c.red("✗"))
+			return
+		}
+		c.restore(args[0])
+	case "reclassify":
+		names := scraper.DefaultReclassifyProcessors
+		if len(args) > 0 {
+			names = strings.Split(args[0], ",")
+		}
+		c.reclassify(names)
+	case "export-search":
+		asJSON := false
+		var terms []string
+		for _, arg := range args {
+			if arg == "--json" {
+				asJSON = true
+				continue
+			}
+			terms = append(terms, arg)
+		}
+		if len(terms) == 0 {
+			fmt.Printf("%s Usage: export-search [--json] <terms...>\n", c.red("✗"))
+			return
+		}
+		c.exportSearch(terms, asJSON)
+	case "import":
+		if len(args) != 1 {
+			fmt.Printf("%s Usage: import <file.jsonl>\n", c.red("✗"))
+			return
+		}
+		c.importData(args[0])
+	case "merge-authors":
+		if len(args) != 2 {
+			fmt.Printf("%s Usage: merge-authors <from> <to>\n", c.red("✗"))
+			return
+		}
+		c.mergeAuthors(args[0], args[1])
+	case "reset":
+		force := len(args) > 0 && args[0] == "--force"
+		c.resetSchema(force)
+	case "reposts":
+		limit := 10
+		if len(args) > 0 {
+			if n, err := strconv.Atoi(args[0]); err == nil {
+				limit = n
+			}
+		}
+		c.showReposts(limit)
+	case "similar":
+		threshold := defaultSimilarThreshold
+		if len(args) > 0 {
+			if t, err := strconv.ParseFloat(args[0], 64); err == nil {
+				threshold = t
+			}
+		}
+		c.showSimilarTitles(threshold)
+	case "growth":
+		days := 14
+		if len(args) > 0 {
+			if n, err := strconv.Atoi(args[0]); err == nil && n > 0 {
+				days = n
+			}
+		}
+		c.showGrowth(days)
+	case "calendar", "cal":
+		year := time.Now().Year()
+		if len(args) > 0 {
+			y, err := strconv.Atoi(args[0])
+			if err != nil || y < 1 {
+				fmt.Printf("%s Invalid year: %s\n", c.red("✗"), args[0])
+				return
+			}
+			year = y
+		}
+		c.showCalendar(year)
+	case "sparkline", "spark":
+		days := 30
+		if len(args) > 0 {
+			if n, err := strconv.Atoi(args[0]); err == nil && n > 0 {
+				days = n
+			}
+		}
+		c.showSparkline(days)
+	case "timings":
+		c.showTimings()
+	case "anomalies":
+		window := 20
+		if len(args) > 0 {
+			if n, err := strconv.Atoi(args[0]); err == nil && n > 0 {
+				window = n
+			}
+		}
+		c.showAnomalies(window)
+	case "churn":
+		runs := 20
+		if len(args) > 0 {
+			if n, err := strconv.Atoi(args[0]); err == nil && n > 0 {
+				runs = n
+			}
+		}
+		c.showChurn(runs)
+	case "reliability":
+		days := 7
+		if len(args) > 0 {
+			if n, err := strconv.Atoi(args[0]); err == nil && n > 0 {
+				days = n
+			}
+		}
+		c.showReliability(days)
+	case "gaps":
+		if len(args) != 2 {
+			fmt.Printf("%s Usage: gaps <startID> <endID>\n", c.red("✗"))
+			return
+		}
+		start, err1 := strconv.Atoi(args[0])
+		end, err2 := strconv.Atoi(args[1])
+		if err1 != nil || err2 != nil {
+			fmt.Printf("%s IDs must be integers\n", c.red("✗"))
+			return
+		}
+		c.showGaps(start, end)
+	case "config":
+		if len(args) > 0 && (args[0] == "export" || args[0] == "import") {
+			if len(args) < 2 {
+				fmt.Printf("%s Usage: config %s <file.json>\n", c.red("✗"), args[0])
+				return
+			}
+			if args[0] == "export" {
+				c.exportConfig(args[1])
+			} else {
+				c.importConfig(args[1])
+			}
+			return
+		}
+		format := "yaml"
+		if len(args) > 0 {
+			format = args[0]
+		}
+		c.showConfig(format)
+	case "test-scraper":
+		name := c.currentScraper.GetConfig().Name
+		if len(args) > 0 {
+			name = args[0]
+		}
+		c.testScraper(name)
+	case "selftest", "verify":
+		RunSelfTest(c.currentScraper)
 	case "scrapers":
 		c.listScrapers()
 	case "clear":
@@ -116,37 +587,97 @@ func (c *Commander) showHelp() {
     fmt.Println("\n" + c.cyan("Scraping:"))
     fmt.Println("  scrape       - Quick scrape (latest page only)")
     fmt.Println("  scrape-new   - Scrape only new posts since last run")
-    fmt.Println("  scrape-all   - Full archive scrape (multiple pages)")
-    fmt.Println("  start/stop   - Start/stop automatic scraping")
+    fmt.Println("  catchup      - Repeat scrape-new runs until the gap since the last run is filled")
+    fmt.Println("  refresh-front - Update scores of already stored posts on page 1, inserting nothing")
+    fmt.Println("  scrape-dates <from> [to] - Backfill the ranked front page of each past day (YYYY-MM-DD)")
+    fmt.Println("  scrape-all [limit] [--resume] - Full archive scrape (multiple pages, optional time limit e.g. 5m)")
+    fmt.Println("               progress is checkpointed per page; --resume continues an interrupted run")
+    fmt.Println("  refresh [n]  - Refresh scores of n recent posts from their item pages")
+    fmt.Println("  benchmark [pages] - Scrape with a fetch/parse/insert timing breakdown")
+    fmt.Println("  start [name] [interval] - Start automatic scraping (e.g. start hackernews 30s)")
+    fmt.Println("  stop [name]  - Stop automatic scraping")
+    fmt.Println("  schedule     - Show last and next run of each scheduled scraper")
     
     fmt.Println("\n" + c.cyan("Analysis:"))
-    fmt.Println("  stats        - Display statistics")
-    fmt.Println("  analyze      - Run statistical analysis")
+    fmt.Println("  stats [--cached] [--format table|json|csv] - Display statistics (--cached reads the last summarize run)")
+    fmt.Println("  summarize    - Precompute the stats summary for stats --cached")
+    fmt.Println("  analyze [--source name] - Run statistical analysis, optionally on one source")
+    fmt.Println("  heatmap      - Posting activity by weekday and hour")
+    fmt.Println("  weekdays     - Posts and average points for each day of the week")
+    fmt.Println("  titlelen     - Average points by title length bucket")
+    fmt.Println("  resurfaced   - Posts whose score jumped again after a long plateau")
+    fmt.Println("  compare <a> <b> - T-test on points between two scraper sources")
+    fmt.Println("  compare-windows <daysA> <daysB> - T-test on points of the last daysA days vs the daysB before")
+    fmt.Println("  concentration - Gini coefficient of the points distribution")
+    fmt.Println("  discussion   - Posts and domains drawing the most comments per point")
+    fmt.Println("  controversial [n] - Heavily commented posts with comparatively few points")
+    fmt.Println("  author-consistency [n] - Authors whose scores vary least and most")
+    fmt.Println("  distribution [points|comments] - Summary of the points or comments distribution")
+    fmt.Println("  percentiles <field> <p,...> - Arbitrary percentiles of a numeric field")
+    fmt.Println("  correlate <a> <b> - Correlation between two numeric fields")
+    fmt.Println("  matrix [fields...] - Correlation matrix of numeric fields")
     
     fmt.Println("\n" + c.cyan("Data:"))
     fmt.Println("  show [n]     - Show n recent posts")
-    fmt.Println("  export       - Export data to CSV")
+    fmt.Println("  today        - Posts stored today, best scoring first, with their scrape time")
+    fmt.Println("  title-changes [n] - Show recently retitled posts")
+    fmt.Println("  tracked [n]  - Posts with the most score history snapshots")
+    fmt.Println("  untracked [n] - Newest posts with no score history at all")
+    fmt.Println("  fastest [points] [n] - Posts that reached points (default 100) soonest after submission")
+    fmt.Println("  growth [days] - Posts collected per day by scraped_at, to spot scraper downtime")
+    fmt.Println("  sparkline [days] - One-line trend of posts collected per day")
+    fmt.Println("  calendar [year] - Month by day grid of posts by post_time, to spot archive gaps")
+    fmt.Println("  timings      - Min, average, p95 and max scrape durations by mode")
+    fmt.Println("  churn [runs] - New vs updated posts per recent run, to right-size the interval")
+    fmt.Println("  anomalies [window] - Runs whose post count or duration is far off the previous runs of their mode")
+    fmt.Println("  reliability [days] - Daily share of scraping jobs that completed without errors")
+    fmt.Println("  gaps <start> <end> - Show hn_id ranges with no stored post")
+    fmt.Println("  reposts [n]  - Links submitted more than once and their best submission")
+    fmt.Println("  similar [t]  - Posts with near-duplicate titles (word overlap >= t, default 0.6)")
+    fmt.Println("  export [cols] [--gzip] - Export data to CSV (optional comma-separated columns)")
+    fmt.Println("  export-history <hn_id> - Export one post's score history as JSON")
+    fmt.Println("  backup <file> [--history] - Write posts (and score history) as JSON lines")
+    fmt.Println("  restore <file> - Replay a backup in one transaction, skipping posts already stored")
+    fmt.Println("  export-search [--json] <terms...> - Export posts whose titles contain every term")
+    fmt.Println("  import <file> - Import posts from a JSON-lines file")
+    fmt.Println("  reclassify [processors] - Re-derive domain and type of stored posts (default domain,type)")
     //TODO: fmt.Println("  history      - Show scraping history")
     
     fmt.Println("\n" + c.cyan("Configuration:"))
     fmt.Println("  scrapers     - List available scrapers")
+    fmt.Println("  selftest     - Check the parser against the live front page")
+    fmt.Println("  test-scraper [name] - Fetch and parse one page with a scraper's selectors, saving nothing")
+    fmt.Println("  config [yaml|json] - Show the effective configuration")
+    fmt.Println("  config export <file> - Write the configuration as JSON, credentials redacted")
+    fmt.Println("  config import <file> - Validate a JSON configuration and switch to it")
+    fmt.Println("  merge-authors <from> <to> - Reassign posts between author names")
+    fmt.Println("  reset --force - Drop all tables and re-run migrations (local dev only)")
     fmt.Println("  clear        - Clear screen")
 }
 
 
 
-func (c *Commander) scrapeAll() {
+func (c *Commander) scrapeAll(maxDuration time.Duration, resume bool) {
     fmt.Println(c.cyan("Starting FULL archive scrape..."))
     fmt.Println(c.yellow("This may take a while and will scrape multiple pages"))
     
     scraperConfig := c.currentScraper.GetConfig()
     
-    smartScraper := scraper.NewSmartScraper(
+    smartScraper, err := scraper.NewSmartScraper(
         c.repo, 
         scraperConfig,
         scraper.ModeFullArchive,
         50,
     )
+    if err != nil {
+        fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+        return
+    }
+    if maxDuration > 0 {
+        smartScraper.SetMaxDuration(maxDuration)
+    }
+    checkpointPath := scraper.DefaultCheckpointPath(scraperConfig.Name)
+    smartScraper.SetCheckpoint(checkpointPath, resume)
     
     result, err := smartScraper.ScrapeWithStrategy()
     
@@ -156,6 +687,11 @@ func (c *Commander) scrapeAll() {
     }
     
     c.printScrapingResult(result)
+
+    if checkpoint, _ := scraper.LoadCheckpoint(checkpointPath); checkpoint != nil {
+        fmt.Printf("%s Archive incomplete, stopped after page %d. Run 'scrape-all --resume' to continue\n",
+            c.yellow("⚠"), checkpoint.LastPage)
+    }
 }
 
 func (c *Commander) scrapeNew() {
@@ -166,12 +702,16 @@ func (c *Commander) scrapeNew() {
     
     scraperConfig := c.currentScraper.GetConfig()
     
-    smartScraper := scraper.NewSmartScraper(
+    smartScraper, err := scraper.NewSmartScraper(
         c.repo,
         scraperConfig,
         scraper.ModeSinceLast,
         10,
     )
+    if err != nil {
+        fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+        return
+    }
     
     result, err := smartScraper.ScrapeWithStrategy()
     
@@ -183,6 +723,159 @@ func (c *Commander) scrapeNew() {
     c.printScrapingResult(result)
 }
 
+func (c *Commander) benchmark(pages int) {
+	mode := scraper.ModeLatestOnly
+	if pages > 1 {
+		mode = scraper.ModeFullArchive
+	}
+	fmt.Println(c.cyan(fmt.Sprintf("Benchmarking %s scrape of %d page(s)...", mode, pages)))
+
+	smartScraper, err := scraper.NewSmartScraper(c.repo, c.currentScraper.GetConfig(), mode, pages)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+	result, err := smartScraper.ScrapeWithStrategy()
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	fmt.Println(c.blue("\nBenchmark Results"))
+	fmt.Println(strings.Repeat("─", 40))
+	fmt.Printf("Pages: %d, posts: %d\n", result.PagesScraped, result.PostsScraped)
+
+	other := result.Duration - result.FetchTime - result.ParseTime - result.InsertTime
+	if other < 0 {
+		other = 0
+	}
+	stages := []struct {
+		name string
+		took time.Duration
+	}{
+		{"fetch", result.FetchTime},
+		{"parse", result.ParseTime},
+		{"insert", result.InsertTime},
+		{"other", other},
+	}
+	for _, stage := range stages {
+		share := 0.0
+		if result.Duration > 0 {
+			share = float64(stage.took) / float64(result.Duration) * 100
+		}
+		fmt.Printf("  %-7s %8.2fs  %5.1f%%\n", stage.name, stage.took.Seconds(), share)
+	}
+	fmt.Printf("  %-7s %8.2fs\n", "total", result.Duration.Seconds())
+	fmt.Println("\n  other includes the polite delay between pages")
+
+	if result.PagesScraped > 0 {
+		fmt.Printf("\nPer page: fetch %.2fs, parse %.3fs, insert %.2fs\n",
+			result.FetchTime.Seconds()/float64(result.PagesScraped),
+			result.ParseTime.Seconds()/float64(result.PagesScraped),
+			result.InsertTime.Seconds()/float64(result.PagesScraped))
+	}
+}
+
+func (c *Commander) catchUp() {
+	fmt.Println(c.cyan("Catching up on posts missed since the last run..."))
+
+	lastID, _ := c.repo.GetLatestHNPostID()
+	fmt.Printf("Last known post ID: %d\n", lastID)
+
+	smartScraper, err := scraper.NewSmartScraper(
+		c.repo,
+		c.currentScraper.GetConfig(),
+		scraper.ModeCatchUp,
+		100,
+	)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	result, err := smartScraper.ScrapeWithStrategy()
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		if result != nil {
+			fmt.Printf("  New posts before failure: %d\n", result.NewPosts)
+		}
+		return
+	}
+
+	c.printScrapingResult(result)
+}
+
+func (c *Commander) refreshFront() {
+	fmt.Println(c.cyan("Refreshing scores of stored posts on the first page..."))
+
+	smartScraper, err := scraper.NewSmartScraper(
+		c.repo,
+		c.currentScraper.GetConfig(),
+		scraper.ModeRefreshFront,
+		1,
+	)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	result, err := smartScraper.ScrapeWithStrategy()
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	c.printScrapingResult(result)
+}
+
+// scrapeDatesMaxDays caps one scrape-dates run, about a year of front pages
+const scrapeDatesMaxDays = 366
+
+func (c *Commander) scrapeDates(from, to time.Time) {
+	if to.Before(from) {
+		fmt.Printf("%s The range ends before it starts\n", c.red("✗"))
+		return
+	}
+	// today's front page is still changing; the archive only holds past days
+	if today := time.Now().UTC().Truncate(24 * time.Hour); !to.Before(today) {
+		fmt.Printf("%s Only past days can be scraped, pick dates before %s\n", c.red("✗"), today.Format("2006-01-02"))
+		return
+	}
+
+	days := int(to.Sub(from).Hours()/24) + 1
+	fmt.Println(c.cyan(fmt.Sprintf("Scraping front pages for %s to %s (%d days)...",
+		from.Format("2006-01-02"), to.Format("2006-01-02"), days)))
+	// each day is one page, so max_pages_limit caps the days as well
+	maxDays, capName := scrapeDatesMaxDays, "one run"
+	if limit := c.config.App.MaxPagesLimit; limit > 0 && limit < maxDays {
+		maxDays, capName = limit, "app.max_pages_limit"
+	}
+	if days > maxDays {
+		fmt.Printf("%s Only the first %d days will be scraped (%s), up to %s\n", c.yellow("⚠"),
+			maxDays, capName, from.AddDate(0, 0, maxDays-1).Format("2006-01-02"))
+	}
+
+	smartScraper, err := scraper.NewSmartScraper(
+		c.repo,
+		c.currentScraper.GetConfig(),
+		scraper.ModeHistoricalFront,
+		maxDays,
+	)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+	smartScraper.SetDateRange(from, to)
+
+	result, err := smartScraper.ScrapeWithStrategy()
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	c.printScrapingResult(result)
+}
+
 func (c *Commander) printScrapingResult(result *scraper.ScrapingResult) {
     fmt.Println(c.green("\n✓ Scraping Complete!"))
     fmt.Println(strings.Repeat("─", 40))
@@ -200,6 +893,28 @@ func (c *Commander) printScrapingResult(result *scraper.ScrapingResult) {
     if result.HighestIDSeen > result.LastKnownID {
         fmt.Printf("ID range:       %d → %d\n", result.LastKnownID, result.HighestIDSeen)
     }
+
+    if result.ResumedAfterPage > 0 {
+        fmt.Printf("Resumed after:  page %d\n", result.ResumedAfterPage)
+    }
+
+    if result.StopReason != "" {
+        fmt.Printf("Note:           %s\n", c.yellow(result.StopReason))
+    }
+
+    for _, alert := range result.Alerts {
+        c.printPointsAlert(alert)
+    }
+
+    if result.ParseWarning != "" {
+        fmt.Printf("%s Possible parser regression: %s\n", c.yellow("⚠"), result.ParseWarning)
+    }
+}
+
+func (c *Commander) printPointsAlert(alert scraper.PointsAlert) {
+	banner := color.New(color.FgBlack, color.BgYellow, color.Bold).SprintFunc()
+	fmt.Printf("%s %s (%d points, threshold %d)\n    %s\n",
+		banner(" ALERT "), alert.Post.Title, alert.Post.Points, alert.Threshold, alert.Post.URL)
 }
 
 func (c *Commander) showScrapingHistory() {
@@ -213,73 +928,141 @@ func (c *Commander) showScrapingHistory() {
     }
     
     for _, job := range history {
-        startTime := job["started_at"].(time.Time)
-        status := job["status"].(string)
-        posts := job["posts_scraped"].(int)
-        
-        statusColor := c.green
-        switch status {
-			case "failed":
-            	statusColor = c.red
-        	case "running":
-            	statusColor = c.yellow
-        }
+        statusColor := c.jobStatusColor(job.Status)
         
         fmt.Printf("%s | %s | %d posts",
-            startTime.Format("Jan 02 15:04"),
-            statusColor(status),
-            posts)
+            job.StartedAt.Format("Jan 02 15:04"),
+            statusColor(job.Status),
+            job.PostsScraped)
         
-        if details, ok := job["details"].(map[string]interface{}); ok {
-            if newPosts, ok := details["new_posts"].(float64); ok {
-                fmt.Printf(" | %s new", c.green(fmt.Sprintf("%.0f", newPosts)))
-            }
-            if pages, ok := details["pages_scraped"].(float64); ok {
-                fmt.Printf(" | %.0f pages", pages)
-            }
+        if job.Details != nil {
+            fmt.Printf(" | %s new", c.green(fmt.Sprintf("%d", job.Details.NewPosts)))
+            fmt.Printf(" | %d pages", job.Details.PagesScraped)
         }
         fmt.Println()
     }
 }
 
-func (c *Commander) scrapeOnce() {
+// partial runs are yellow so a limping scraper stands out from clean ones
+func (c *Commander) jobStatusColor(status string) func(a ...interface{}) string {
+	switch status {
+	case models.JobStatusFailed:
+		return c.red
+	case models.JobStatusPartial:
+		return c.yellow
+	case models.JobStatusRunning:
+		return c.cyan
+	default:
+		return c.green
+	}
+}
+
+// quitGracePeriod is how long quit waits for cancelled scrapes to return
+const quitGracePeriod = 5 * time.Second
+
+func (c *Commander) scrapeOnce() error {
 	fmt.Printf(c.cyan("Scraping %s...\n"), c.currentScraperName)
-	count, err := c.currentScraper.ScrapeOnce()
+
+	ctx := context.Background()
+	if timeout := config.Get().App.ScrapeTimeout; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	count, err := c.currentScraper.ScrapeOnceContext(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		fmt.Printf("%s Scrape timed out after %s (app.scrape_timeout)\n", c.red("✗"), config.Get().App.ScrapeTimeout)
+		return err
+	}
 	if err != nil {
 		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
-		return
+		return err
 	}
 	fmt.Printf("%s Scraped %d posts from %s\n", c.green("✓"), count, c.currentScraperName)
+	return nil
 }
 
-func (c *Commander) startAutoScraping() {
-	scraperConfig := c.currentScraper.GetConfig()
-	
-	if c.scheduler.IsActive(c.currentScraperName) {
-		fmt.Printf("%s Auto-scraping for %s is already active\n", 
-			c.yellow("⚠"), c.currentScraperName)
+func (c *Commander) refreshScores(limit int) {
+	fmt.Printf(c.cyan("Refreshing scores for up to %d recent posts...\n"), limit)
+	refreshed, checked, err := c.currentScraper.RefreshPosts(limit)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
 		return
 	}
-	
-	c.scheduler.StartScraper(c.currentScraperName, scraperConfig.Interval)
-	fmt.Printf("%s Started auto-scraping %s (every %s)\n", 
-		c.green("✓"), c.currentScraperName, scraperConfig.Interval)
-}
-
-func (c *Commander) stopAutoScraping() {
-	if !c.scheduler.IsActive(c.currentScraperName) {
-		fmt.Printf("%s Auto-scraping for %s is not active\n", 
-			c.yellow("⚠"), c.currentScraperName)
+	if checked == 0 {
+		fmt.Printf("%s All recent posts were updated within the last hour\n", c.yellow("⚠"))
 		return
 	}
-	
-	c.scheduler.StopScraper(c.currentScraperName)
-	fmt.Printf("%s Stopped auto-scraping for %s\n", c.green("✓"), c.currentScraperName)
+	fmt.Printf("%s Refreshed %d of %d posts\n", c.green("✓"), refreshed, checked)
 }
 
-func (c *Commander) showStatus() {
-	fmt.Println(c.blue("\n System Status"))
-	fmt.Println(strings.Repeat("─", 40))
+func (c *Commander) startAutoScraping(args []string) {
+	name := c.currentScraperName
+	if len(args) > 0 {
+		name = args[0]
+	}
+
+	scraperConfig, err := config.GetScraper(name)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	interval := scraperConfig.Interval
+	if len(args) > 1 {
+		interval, err = parseInterval(args[1])
+		if err != nil {
+			fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+			return
+		}
+	}
+	
+	if c.scheduler.IsActive(name) {
+		fmt.Printf("%s Auto-scraping for %s is already active\n", 
+			c.yellow("⚠"), name)
+		return
+	}
+	
+	if err := c.scheduler.StartScraper(name, interval); err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+	fmt.Printf("%s Started auto-scraping %s (every %s)\n", 
+		c.green("✓"), name, interval)
+}
+
+// sub-second intervals would hammer the target site
+func parseInterval(value string) (time.Duration, error) {
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid interval %q (use e.g. 30s, 5m, 1h)", value)
+	}
+	if interval < time.Second {
+		return 0, fmt.Errorf("interval %s is too small, minimum is 1s", interval)
+	}
+	return interval, nil
+}
+
+func (c *Commander) stopAutoScraping(args []string) {
+	name := c.currentScraperName
+	if len(args) > 0 {
+		name = args[0]
+	}
+
+	if !c.scheduler.IsActive(name) {
+		fmt.Printf("%s Auto-scraping for %s is not active\n", 
+			c.yellow("⚠"), name)
+		return
+	}
+	
+	c.scheduler.StopScraper(name)
+	fmt.Printf("%s Stopped auto-scraping for %s\n", c.green("✓"), name)
+}
+
+func (c *Commander) showStatus() {
+	fmt.Println(c.blue("\n System Status"))
+	fmt.Println(strings.Repeat("─", 40))
 	
 	fmt.Printf("Current scraper: %s\n", c.cyan(c.currentScraperName))
 	
@@ -297,8 +1080,8 @@ func (c *Commander) showStatus() {
 	}
 	
 	if job, err := c.repo.GetLastScrapingJob(); err == nil && job != nil {
-		fmt.Printf("Last scrape:     %s (%d posts)\n",
-			job.CompletedAt.Format("15:04:05"), job.PostsScraped)
+		fmt.Printf("Last scrape:     %s (%d posts, %s)\n",
+			job.CompletedAt.Format("15:04:05"), job.PostsScraped, c.jobStatusColor(job.Status)(job.Status))
 	}
 	
 	if count, err := c.repo.GetPostCount(); err == nil {
@@ -314,74 +1097,1239 @@ func (c *Commander) showStatus() {
 	fmt.Printf("Today's posts:   %d\n", todayCount)
 }
 
-func (c *Commander) showStatistics() {
+func (c *Commander) showSchedule() {
+	entries := c.scheduler.Schedule()
+
+	fmt.Println(c.blue("\nScraper Schedule"))
+	fmt.Println(strings.Repeat("─", 70))
+
+	if len(entries) == 0 {
+		fmt.Println("No scrapers are scheduled (use 'start' to schedule one)")
+		return
+	}
+
+	fmt.Printf("%-20s %-10s %-20s %s\n", "Scraper", "Interval", "Last run", "Next run")
+	for _, entry := range entries {
+		lastRun := "never"
+		if !entry.LastRun.IsZero() {
+			lastRun = entry.LastRun.Format("2006-01-02 15:04:05")
+		}
+		nextIn := time.Until(entry.NextRun).Round(time.Second)
+		fmt.Printf("%-20s %-10s %-20s %s (in %s)\n",
+			entry.Scraper, entry.Interval, lastRun,
+			entry.NextRun.Format("15:04:05"), c.cyan(nextIn))
+	}
+}
+
+// statsTopHours is how many hours stats lists under peak posting hours
+const statsTopHours = 5
+
+// showStatistics prints the stats summary as a table, or as json or csv for
+// scripts. in the machine formats warnings go to stderr so stdout stays
+// parseable.
+func (c *Commander) showStatistics(cached bool, format string) {
+	warn := os.Stdout
+	if format != "table" {
+		warn = os.Stderr
+	}
+
+	var summary *analyzer.StatsSummary
+	var err error
+	if cached {
+		summary, err = c.descriptiveAnalyzer.CachedSummary()
+		if err != nil {
+			fmt.Fprintf(warn, "%s Could not read cached summary: %v\n", c.yellow("⚠"), err)
+		} else if summary == nil {
+			fmt.Fprintf(warn, "%s No cached summary yet, run 'summarize' first; computing live\n", c.yellow("⚠"))
+		}
+	}
+	if summary == nil {
+		summary, err = c.descriptiveAnalyzer.ComputeSummary(c.config.App.Analysis.MinPostsForAuthorStats)
+		if err != nil {
+			fmt.Fprintf(warn, "%s Error: %v\n", c.red("✗"), err)
+			return
+		}
+	}
+
+	switch format {
+	case "json":
+		err = writeStatsJSON(summary)
+	case "csv":
+		err = writeStatsCSV(summary)
+	default:
+		c.printStatistics(summary)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s Error: %v\n", c.red("✗"), err)
+	}
+}
+
+// peakHours returns the busiest hours by post count, busiest first
+func peakHours(patterns []analyzer.HourlyPattern, n int) []analyzer.HourlyPattern {
+	sorted := append([]analyzer.HourlyPattern{}, patterns...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].PostCount > sorted[j].PostCount })
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+func writeStatsJSON(summary *analyzer.StatsSummary) error {
+	out := struct {
+		*analyzer.StatsSummary
+		PeakHours  []analyzer.HourlyPattern `json:"peak_hours"`
+		ComputedAt *time.Time               `json:"computed_at,omitempty"`
+	}{StatsSummary: summary, PeakHours: peakHours(summary.HourlyPatterns, statsTopHours)}
+	if !summary.ComputedAt.IsZero() {
+		out.ComputedAt = &summary.ComputedAt
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(out)
+}
+
+// writeStatsCSV writes the headline metrics as metric,value rows
+func writeStatsCSV(summary *analyzer.StatsSummary) error {
+	w := csv.NewWriter(os.Stdout)
+	rows := [][]string{
+		{"metric", "value"},
+		{"total_posts", strconv.Itoa(summary.TotalPosts)},
+		{"unique_authors", strconv.Itoa(summary.UniqueAuthors)},
+		{"avg_points", strconv.FormatFloat(summary.AvgPoints, 'f', 2, 64)},
+		{"avg_comments", strconv.FormatFloat(summary.AvgComments, 'f', 2, 64)},
+		{"max_points", strconv.Itoa(summary.MaxPoints)},
+		{"max_comments", strconv.Itoa(summary.MaxComments)},
+	}
+	for _, p := range peakHours(summary.HourlyPatterns, statsTopHours) {
+		rows = append(rows, []string{fmt.Sprintf("posts_at_%02d", p.Hour), strconv.Itoa(p.PostCount)})
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write stats: %w", err)
+	}
+	return nil
+}
+
+func (c *Commander) printStatistics(summary *analyzer.StatsSummary) {
 	fmt.Println(c.blue("\nDatabase Statistics"))
+	if !summary.ComputedAt.IsZero() {
+		fmt.Printf("(cached as of %s)\n", summary.ComputedAt.Format("2006-01-02 15:04:05"))
+	}
 	fmt.Println(strings.Repeat("─", 50))
 	
-	if stats, err := c.descriptiveAnalyzer.BasicStatistics(); err == nil {
-		fmt.Printf("Total posts:      %d\n", stats["total_posts"])
-		fmt.Printf("Unique authors:   %d\n", stats["unique_authors"])
-		fmt.Printf("Average points:   %.1f\n", stats["avg_points"])
-		fmt.Printf("Average comments: %.1f\n", stats["avg_comments"])
-		fmt.Printf("Max points:       %d\n", stats["max_points"])
-		fmt.Printf("Max comments:     %d\n", stats["max_comments"])
+	fmt.Printf("Total posts:      %d\n", summary.TotalPosts)
+	fmt.Printf("Unique authors:   %d\n", summary.UniqueAuthors)
+	fmt.Printf("Average points:   %.1f\n", summary.AvgPoints)
+	fmt.Printf("Average comments: %.1f\n", summary.AvgComments)
+	fmt.Printf("Max points:       %d\n", summary.MaxPoints)
+	fmt.Printf("Max comments:     %d\n", summary.MaxComments)
+	
+	fmt.Println(c.blue("\nTop 5 Posts by Points:"))
+	for i, post := range summary.TopPosts {
+		title := post.Title
+		if len(title) > 50 {
+			title = title[:50] + "..."
+		}
+		fmt.Printf("%d. %s\n   %s (%d points)\n", 
+			i+1, title, post.Author, post.Points)
+	}
+
+	if len(summary.TopAuthors) > 0 {
+		fmt.Println(c.blue("\nTop Authors by Average Points:"))
+		for _, a := range summary.TopAuthors {
+			fmt.Printf("  %-20s %3d posts, avg %.1f points\n", a.Author, a.PostCount, a.AvgPoints)
+		}
+	}
+
+	if len(summary.TopDomains) > 0 {
+		fmt.Println(c.blue("\nTop Domains:"))
+		for _, d := range summary.TopDomains {
+			fmt.Printf("  %-30s %3d posts, avg %.1f points\n", d.Domain, d.PostCount, d.AvgPoints)
+		}
+	}
+	
+	fmt.Println(c.blue("\nPeak Posting Hours:"))
+	for _, p := range peakHours(summary.HourlyPatterns, statsTopHours) {
+		fmt.Printf("  %02d:00 - %d posts (avg %.1f points)\n",
+			p.Hour, p.PostCount, p.AvgPoints)
+	}
+}
+
+func (c *Commander) summarize() {
+	fmt.Println(c.cyan("Computing stats summary..."))
+
+	start := time.Now()
+	summary, err := c.descriptiveAnalyzer.Summarize(c.config.App.Analysis.MinPostsForAuthorStats)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	fmt.Printf("%s Summary of %d posts saved at %s (took %s)\n", c.green("✓"),
+		summary.TotalPosts, summary.ComputedAt.Format("2006-01-02 15:04:05"), time.Since(start).Round(time.Millisecond))
+	fmt.Println("Use 'stats --cached' to read it")
+}
+
+func (c *Commander) showDiscussion() {
+	stats, err := c.descriptiveAnalyzer.GetDiscussionRatioStats()
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+	if stats.PostCount == 0 {
+		fmt.Printf("%s No posts with enough points to compute a discussion ratio\n", c.yellow("⚠"))
+		return
+	}
+
+	fmt.Println(c.blue("\nDiscussion Ratio (comments per point)"))
+	fmt.Println(strings.Repeat("─", 60))
+	fmt.Printf("Posts considered: %d\n", stats.PostCount)
+	fmt.Printf("Overall ratio:    %.3f\n", stats.OverallRatio)
+	fmt.Printf("Mean ratio:       %.3f\n", stats.MeanRatio)
+	fmt.Printf("Median ratio:     %.3f\n", stats.MedianRatio)
+
+	fmt.Println(c.cyan("\nMost discussed relative to score:"))
+	for i, dp := range stats.TopPosts {
+		title := dp.Post.Title
+		if len(title) > 50 {
+			title = title[:50] + "..."
+		}
+		fmt.Printf("%2d. %s\n    %.2f (%d comments / %d points)\n",
+			i+1, title, dp.Ratio, dp.Post.CommentsCount, dp.Post.Points)
+	}
+
+	if len(stats.TopDomains) > 0 {
+		fmt.Println(c.cyan("\nDomains:"))
+		for _, dd := range stats.TopDomains {
+			fmt.Printf("  %-30s %.2f over %d posts\n", dd.Domain, dd.Ratio, dd.PostCount)
+		}
+	}
+}
+
+func (c *Commander) showControversial(limit int) {
+	analysis := c.config.App.Analysis
+	posts, err := c.descriptiveAnalyzer.GetControversialPosts(analysis.ControversialRatio, analysis.ControversialMinComments, limit)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	fmt.Println(c.blue("\nControversial Posts"))
+	fmt.Println(strings.Repeat("─", 60))
+	fmt.Printf("At least %d comments and %.2f comments per point\n\n",
+		analysis.ControversialMinComments, analysis.ControversialRatio)
+
+	if len(posts) == 0 {
+		fmt.Println("No posts match, try lowering controversial_ratio or controversial_min_comments")
+		return
+	}
+
+	for i, dp := range posts {
+		title := dp.Post.Title
+		if len(title) > 50 {
+			title = title[:50] + "..."
+		}
+		fmt.Printf("%2d. %s\n    %s (%d comments / %d points) by %s, %s\n",
+			i+1, title, c.yellow(fmt.Sprintf("%.2f", dp.Ratio)), dp.Post.CommentsCount, dp.Post.Points,
+			dp.Post.Author, formatPostTime(dp.Post.PostTime, "2006-01-02"))
+	}
+}
+
+func (c *Commander) showAuthorConsistency(limit int) {
+	minPosts := c.config.App.Analysis.MinPostsForAuthorStats
+	authors, err := c.descriptiveAnalyzer.GetAuthorConsistency(minPosts)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	fmt.Println(c.blue("\nAuthor Consistency"))
+	fmt.Println(strings.Repeat("─", 60))
+
+	if len(authors) == 0 {
+		fmt.Printf("No authors with at least %d scored posts\n", minPosts)
+		return
+	}
+
+	printAuthor := func(ac analyzer.AuthorConsistency) {
+		fmt.Printf("  %-20s %3d posts, avg %6.1f, stddev %6.1f, cv %s\n",
+			ac.Author, ac.PostCount, ac.AvgPoints, ac.StdDev, c.yellow(fmt.Sprintf("%.2f", ac.CV)))
+	}
+
+	// with few authors the two lists would overlap, so show them once
+	if len(authors) <= 2*limit {
+		fmt.Println("Most consistent first:")
+		for _, ac := range authors {
+			printAuthor(ac)
+		}
+		return
+	}
+
+	fmt.Println(c.cyan("Most consistent:"))
+	for _, ac := range authors[:limit] {
+		printAuthor(ac)
+	}
+	fmt.Println(c.cyan("\nLeast consistent:"))
+	for i := len(authors) - 1; i >= len(authors)-limit; i-- {
+		printAuthor(authors[i])
+	}
+}
+
+func (c *Commander) showWeekdays() {
+	stats, err := c.descriptiveAnalyzer.GetDayOfWeekStats()
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	fmt.Println(c.blue("\nPosts by Day of Week"))
+	fmt.Println(strings.Repeat("─", 60))
+	fmt.Printf("%-10s %8s %10s\n", "Day", "Posts", "Avg Points")
+
+	maxCount, bestDay := 0, -1
+	for i, s := range stats {
+		if s.PostCount > maxCount {
+			maxCount = s.PostCount
+		}
+		if s.PostCount > 0 && (bestDay < 0 || s.AvgPoints > stats[bestDay].AvgPoints) {
+			bestDay = i
+		}
+	}
+
+	for i, s := range stats {
+		bar := ""
+		if maxCount > 0 {
+			bar = strings.Repeat("█", s.PostCount*30/maxCount)
+		}
+		avg := fmt.Sprintf("%10.1f", s.AvgPoints)
+		if i == bestDay {
+			avg = c.green(avg)
+		}
+		fmt.Printf("%-10s %8d %s %s\n", s.Day, s.PostCount, avg, bar)
+	}
+}
+
+func (c *Commander) showTitleLengths() {
+	buckets, err := c.descriptiveAnalyzer.GetTitleLengthBuckets()
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	fmt.Println(c.blue("\nPoints by Title Length"))
+	fmt.Println(strings.Repeat("─", 60))
+
+	if len(buckets) == 0 {
+		fmt.Println("No posts stored yet")
+		return
+	}
+
+	fmt.Printf("%-10s %8s %10s\n", "Length", "Posts", "Avg Points")
+
+	maxAvg, best := 0.0, 0
+	for i, b := range buckets {
+		if b.AvgPoints > maxAvg {
+			maxAvg, best = b.AvgPoints, i
+		}
+	}
+
+	for i, b := range buckets {
+		label := fmt.Sprintf("%d-%d", b.MinLen, b.MaxLen)
+		if b.MaxLen == 0 {
+			label = fmt.Sprintf("%d+", b.MinLen)
+		}
+		bar := ""
+		if maxAvg > 0 {
+			bar = strings.Repeat("█", int(b.AvgPoints/maxAvg*30))
+		}
+		avg := fmt.Sprintf("%10.1f", b.AvgPoints)
+		if i == best {
+			avg = c.green(avg)
+		}
+		fmt.Printf("%-10s %8d %s %s\n", label, b.PostCount, avg, bar)
+	}
+}
+
+func (c *Commander) showHeatmap() {
+	heatmap, err := c.descriptiveAnalyzer.GetActivityHeatmap()
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	maxCount := 0
+	for _, hours := range heatmap {
+		for _, count := range hours {
+			if count > maxCount {
+				maxCount = count
+			}
+		}
+	}
+
+	fmt.Println(c.blue("\nPosting Activity (day × hour)"))
+	fmt.Println(strings.Repeat("─", 54))
+
+	fmt.Print("     ")
+	for hour := 0; hour < 24; hour += 3 {
+		fmt.Printf("%-6s", fmt.Sprintf("%02d", hour))
+	}
+	fmt.Println()
+
+	shades := []string{" ", "░", "▒", "▓", "█"}
+	dayNames := []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
+	// monday first, sunday last
+	for _, dow := range []int{1, 2, 3, 4, 5, 6, 0} {
+		fmt.Printf("%s  ", dayNames[dow])
+		for hour := 0; hour < 24; hour++ {
+			level := 0
+			if maxCount > 0 && heatmap[dow][hour] > 0 {
+				level = 1 + heatmap[dow][hour]*(len(shades)-2)/maxCount
+			}
+			fmt.Print(strings.Repeat(shades[level], 2))
+		}
+		fmt.Println()
+	}
+
+	fmt.Printf("\nScale: ░ low → █ %d posts\n", maxCount)
+}
+
+func (c *Commander) showResurfaced() {
+	posts, err := c.descriptiveAnalyzer.GetResurfacedPosts()
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	fmt.Println(c.blue("\nResurfaced Posts"))
+	fmt.Println(strings.Repeat("─", 70))
+
+	if len(posts) == 0 {
+		fmt.Println("No resurfaced posts found (needs score history from repeated scrapes)")
+		return
+	}
+
+	for _, p := range posts {
+		title := p.Title
+		if len(title) > 60 {
+			title = title[:57] + "..."
+		}
+		flat := p.ResurfacedAt.Sub(p.FlatSince).Round(time.Minute)
+		fmt.Printf("[%d] %s\n", p.HnID, title)
+		fmt.Printf("     %d → %s points after %s flat, at %s\n",
+			p.FlatPoints, c.green(fmt.Sprintf("%d", p.Points)), flat, p.ResurfacedAt.Format("2006-01-02 15:04"))
+	}
+}
+
+func (c *Commander) showConcentration() {
+	gini, err := c.descriptiveAnalyzer.GetPointsGini()
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	fmt.Println(c.blue("\nPoints Concentration"))
+	fmt.Println(strings.Repeat("─", 40))
+	fmt.Printf("Gini coefficient: %.3f\n", gini)
+
+	switch {
+	case gini < 0.3:
+		fmt.Println("   → points are spread fairly evenly")
+	case gini < 0.6:
+		fmt.Println("   → points are moderately concentrated")
+	default:
+		fmt.Println("   → a few posts take most of the points")
+	}
+}
+
+func (c *Commander) showDistribution(field string) {
+	var dist *analyzer.Distribution
+	var err error
+	var title string
+	switch field {
+	case "points":
+		title = "Points"
+		dist, err = c.descriptiveAnalyzer.GetPointsDistribution()
+	case "comments":
+		title = "Comments"
+		dist, err = c.descriptiveAnalyzer.GetCommentsDistribution()
+	default:
+		fmt.Printf("%s Usage: distribution [points|comments]\n", c.red("✗"))
+		return
+	}
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	fmt.Println(c.blue(fmt.Sprintf("\n%s Distribution", title)))
+	fmt.Println(strings.Repeat("─", 40))
+	fmt.Printf("Min:     %.0f\n", dist.Min)
+	fmt.Printf("Q1:      %.1f\n", dist.Percentile25)
+	fmt.Printf("Median:  %.1f\n", dist.Median)
+	fmt.Printf("Q3:      %.1f\n", dist.Percentile75)
+	fmt.Printf("Max:     %.0f\n", dist.Max)
+	fmt.Printf("Mean:    %.2f\n", dist.Mean)
+	fmt.Printf("Std Dev: %.2f\n", dist.StdDev)
+
+	// a mean well above the median means a long right tail
+	if dist.Median > 0 {
+		fmt.Printf("Mean/median ratio: %.2f\n", dist.Mean/dist.Median)
+	}
+}
+
+func (c *Commander) showPercentiles(field, list string) {
+	fractions, err := parsePercentiles(list)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	values, err := c.descriptiveAnalyzer.GetPercentiles(field, fractions)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	fmt.Println(c.blue(fmt.Sprintf("\nPercentiles of %s", field)))
+	fmt.Println(strings.Repeat("─", 30))
+	for i, fraction := range fractions {
+		label := strconv.FormatFloat(math.Round(fraction*10000)/100, 'f', -1, 64)
+		fmt.Printf("  p%-6s %10.2f\n", label, values[i])
+	}
+}
+
+// parsePercentiles accepts "10,50,90", "p10,p50" or "0.1,0.5"; values are
+// read as fractions when all of them are at most 1, otherwise as percents
+func parsePercentiles(list string) ([]float64, error) {
+	var values []float64
+	asPercent := false
+	for _, part := range strings.Split(list, ",") {
+		part = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(part)), "p")
+		if part == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid percentile %q", part)
+		}
+		if v > 1 {
+			asPercent = true
+		}
+		values = append(values, v)
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no percentiles given")
+	}
+
+	for i, v := range values {
+		if asPercent {
+			if v <= 0 || v > 100 {
+				return nil, fmt.Errorf("percentile %g must be in (0,100]", v)
+			}
+			values[i] = v / 100
+		} else if v <= 0 {
+			return nil, fmt.Errorf("percentile %g must be in (0,1]", v)
+		}
+	}
+	return values, nil
+}
+
+func (c *Commander) showRecentPosts(limit int) {
+	fmt.Printf(c.blue("\nRecent %d Posts:\n"), limit)
+	fmt.Println(strings.Repeat("─", 70))
+	
+	posts, err := c.repo.GetRecentPosts(limit)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+	
+	c.printPostList(posts)
+}
+
+func (c *Commander) showTodaysPosts() {
+	posts, err := c.repo.GetTodaysPosts()
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	fmt.Printf(c.blue("\n%d Posts Stored Today:\n"), len(posts))
+	fmt.Println(strings.Repeat("─", 70))
+	if len(posts) == 0 {
+		fmt.Println("Nothing stored since midnight yet")
+		return
+	}
+
+	c.printPostList(posts)
+}
+
+// printPostList prints posts with their author, score and scrape time
+func (c *Commander) printPostList(posts []models.Post) {
+	for _, post := range posts {
+		title := post.Title
+		if len(title) > 60 {
+			title = title[:60] + "..."
+		}
+		
+		fmt.Printf("\n%s %s\n", c.green("+"), title)
+		fmt.Printf("  by %s | %d points | %d comments | %s\n",
+			post.Author, post.Points, post.CommentsCount,
+			post.ScrapedAt.Format("15:04"))
+	}
+}
+
+func (c *Commander) showTitleChanges(limit int) {
+	fmt.Println(c.blue("\nRecent Title Changes"))
+	fmt.Println(strings.Repeat("─", 70))
+
+	changes, err := c.repo.GetTitleChanges(limit)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+	if len(changes) == 0 {
+		fmt.Println("No title changes recorded yet")
+		return
+	}
+
+	for _, change := range changes {
+		fmt.Printf("\n%s #%d (%s)\n", c.yellow("~"), change.HnID, change.ChangedAt.Format("Jan 02 15:04"))
+		fmt.Printf("  - %s\n", change.OldTitle)
+		fmt.Printf("  + %s\n", c.green(change.NewTitle))
+	}
+}
+
+func (c *Commander) showReposts(limit int) {
+	groups, err := c.repo.GetReposts()
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	fmt.Println(c.blue("\nReposted Links"))
+	fmt.Println(strings.Repeat("─", 70))
+
+	if len(groups) == 0 {
+		fmt.Println("No link has been submitted more than once")
+		return
+	}
+
+	reposts := 0
+	for _, group := range groups {
+		reposts += len(group.Posts) - 1
+	}
+	fmt.Printf("%d links submitted more than once (%d extra submissions)\n\n", len(groups), reposts)
+
+	for i, group := range groups {
+		if i == limit {
+			break
+		}
+		best := group.Posts[0]
+		fmt.Printf("%s (%d submissions)\n", c.cyan(group.NormalizedURL), len(group.Posts))
+		for _, post := range group.Posts {
+			marker := " "
+			if post.HnID == best.HnID {
+				marker = c.green("★")
+			}
+			fmt.Printf("  %s [%d] %d points, %d comments, %s by %s\n", marker,
+				post.HnID, post.Points, post.CommentsCount, formatPostTime(post.PostTime, "2006-01-02"), post.Author)
+		}
+	}
+}
+
+// defaultSimilarThreshold is the title word overlap similar uses when none
+// is given; lower values start matching unrelated stories on shared topics
+const defaultSimilarThreshold = 0.6
+
+// similarClusterLimit caps how many clusters similar prints
+const similarClusterLimit = 20
+
+func (c *Commander) showSimilarTitles(threshold float64) {
+	clusters, err := c.descriptiveAnalyzer.GetSimilarTitles(threshold)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	fmt.Println(c.blue("\nSimilar Titles"))
+	fmt.Println(strings.Repeat("─", 70))
+
+	if len(clusters) == 0 {
+		fmt.Printf("No titles overlap by %.0f%% or more\n", threshold*100)
+		return
+	}
+
+	fmt.Printf("%d clusters of near-duplicate titles (threshold %.2f)\n\n", len(clusters), threshold)
+
+	for i, cluster := range clusters {
+		if i == similarClusterLimit {
+			fmt.Printf("... and %d more\n", len(clusters)-similarClusterLimit)
+			break
+		}
+		fmt.Printf("%s (%d posts, similarity >= %.2f)\n", c.cyan(cluster.Posts[0].Title), len(cluster.Posts), cluster.Similarity)
+		for _, post := range cluster.Posts {
+			fmt.Printf("  [%d] %d points, %s by %s: %s\n",
+				post.HnID, post.Points, formatPostTime(post.PostTime, "2006-01-02"), post.Author, post.Title)
+		}
+	}
+}
+
+// testScraperSample is how many parsed posts test-scraper prints in full
+const testScraperSample = 5
+
+func (c *Commander) testScraper(name string) {
+	// no repository: nothing this scraper parses can be stored
+	s, err := scraper.NewGenericScraper(nil, name)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+	cfg := s.GetConfig()
+
+	fmt.Println(c.blue(fmt.Sprintf("\nTesting scraper %s", name)))
+	fmt.Println(strings.Repeat("─", 60))
+	fmt.Printf("URL:          %s\n", cfg.URL)
+	fmt.Printf("Item:         %s\n", cfg.Selectors.Item)
+	fmt.Printf("Metadata row: %s\n", cfg.Selectors.MetadataRow)
+	fmt.Printf("Title:        %s\n", cfg.Selectors.Title)
+	fmt.Printf("Points:       %s\n", cfg.Selectors.Points)
+	fmt.Printf("Author:       %s\n", cfg.Selectors.Author)
+
+	start := time.Now()
+	posts, err := s.Fetch()
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+	fmt.Printf("\nParsed %d posts in %s\n", len(posts), time.Since(start).Round(time.Millisecond))
+	if len(posts) == 0 {
+		fmt.Printf("%s No posts matched, check the item selector\n", c.yellow("⚠"))
+		return
+	}
+
+	titled, linked, scored, authored, commented := 0, 0, 0, 0, 0
+	for _, p := range posts {
+		if p.Title != "" {
+			titled++
+		}
+		if p.URL != "" {
+			linked++
+		}
+		if p.Points > 0 {
+			scored++
+		}
+		if p.Author != "" && p.Author != "unknown" {
+			authored++
+		}
+		if p.CommentsCount > 0 {
+			commented++
+		}
+	}
+	field := func(label string, n int) {
+		marker := c.green("✓")
+		if n == 0 {
+			marker = c.yellow("⚠")
+		}
+		fmt.Printf("  %s %-9s %d/%d\n", marker, label, n, len(posts))
+	}
+	field("title", titled)
+	field("url", linked)
+	field("points", scored)
+	field("author", authored)
+	field("comments", commented)
+
+	sample := posts
+	if len(sample) > testScraperSample {
+		sample = sample[:testScraperSample]
+	}
+	fmt.Println(c.cyan(fmt.Sprintf("\nFirst %d posts:", len(sample))))
+	for _, p := range sample {
+		fmt.Printf("  [%d] %s\n", p.HnID, p.Title)
+		fmt.Printf("       %s\n", p.URL)
+		fmt.Printf("       %d points, %d comments, by %s at %s\n",
+			p.Points, p.CommentsCount, p.Author, formatPostTime(p.PostTime, "2006-01-02 15:04"))
+	}
+}
+
+func (c *Commander) showTracked(limit int) {
+	tracked, err := c.repo.GetMostTrackedPosts(limit)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+	if len(tracked) == 0 {
+		fmt.Println(c.yellow("No score history recorded yet"))
+		return
+	}
+
+	fmt.Println(c.blue("\nLongest Tracked Posts"))
+	fmt.Println(strings.Repeat("─", 70))
+	for i, tp := range tracked {
+		title := tp.Post.Title
+		if len(title) > 50 {
+			title = title[:50] + "..."
+		}
+		span := tp.LastSeen.Sub(tp.FirstSeen).Round(time.Minute)
+		fmt.Printf("%2d. %s\n    %d snapshots over %s (%s → %s), now %d points\n",
+			i+1, title, tp.Snapshots, span,
+			tp.FirstSeen.Format("01-02 15:04"), tp.LastSeen.Format("01-02 15:04"), tp.Post.Points)
+	}
+}
+
+func (c *Commander) showFastest(threshold, limit int) {
+	fastest, err := c.repo.GetFastestToThreshold(threshold, limit)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+	if len(fastest) == 0 {
+		fmt.Printf("%s No post history reaches %d points yet\n", c.yellow("⚠"), threshold)
+		return
+	}
+
+	fmt.Println(c.blue(fmt.Sprintf("\nFastest to %d Points", threshold)))
+	fmt.Println(strings.Repeat("─", 70))
+	for i, tp := range fastest {
+		title := tp.Post.Title
+		if len(title) > 50 {
+			title = title[:50] + "..."
+		}
+		fmt.Printf("%2d. %s\n    %s after posting, by %s, now %d points\n",
+			i+1, title, c.green(tp.Elapsed.Round(time.Minute).String()), tp.Post.Author, tp.Post.Points)
+	}
+	fmt.Println("\nTimes are measured to the first snapshot at or above the threshold")
+}
+
+func (c *Commander) showUntracked(limit int) {
+	posts, err := c.repo.GetPostsWithoutHistory(limit)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+	if len(posts) == 0 {
+		fmt.Printf("%s Every stored post has score history\n", c.green("✓"))
+		return
+	}
+
+	fmt.Println(c.blue("\nPosts Without Score History"))
+	fmt.Println(strings.Repeat("─", 70))
+	for i, post := range posts {
+		title := post.Title
+		if len(title) > 50 {
+			title = title[:50] + "..."
+		}
+		fmt.Printf("%2d. [%d] %s\n    %d points, posted %s, stored %s\n",
+			i+1, post.HnID, title, post.Points,
+			formatPostTime(post.PostTime, "2006-01-02 15:04"), post.ScrapedAt.Format("2006-01-02 15:04"))
+	}
+	fmt.Println("\nUse 'refresh' to record a snapshot for recent posts")
+}
+
+func (c *Commander) showGrowth(days int) {
+	counts, err := c.repo.GetCollectionRate(days)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	fmt.Println(c.blue(fmt.Sprintf("\nPosts collected per day (last %d days)", days)))
+	fmt.Println(strings.Repeat("─", 40))
+
+	maxPosts, total, idle := 0, 0, 0
+	for _, dc := range counts {
+		if dc.Posts > maxPosts {
+			maxPosts = dc.Posts
+		}
+		total += dc.Posts
+		if dc.Posts == 0 {
+			idle++
+		}
+	}
+
+	for _, dc := range counts {
+		if dc.Posts == 0 {
+			fmt.Printf("  %s %6d %s\n", dc.Date, 0, c.yellow("⚠ nothing collected"))
+			continue
+		}
+		bar := strings.Repeat("█", int(math.Ceil(float64(dc.Posts)/float64(maxPosts)*30)))
+		fmt.Printf("  %s %6d %s\n", dc.Date, dc.Posts, bar)
+	}
+
+	fmt.Println(strings.Repeat("─", 40))
+	fmt.Printf("Total: %d posts, %.1f/day", total, float64(total)/float64(len(counts)))
+	if idle > 0 {
+		fmt.Printf(", %d idle day(s)", idle)
+	}
+	fmt.Println()
+}
+
+func (c *Commander) showSparkline(days int) {
+	counts, err := c.repo.GetCollectionRate(days)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+	if len(counts) == 0 {
+		fmt.Println("No data")
+		return
+	}
+
+	values := make([]int, len(counts))
+	minPosts, maxPosts := counts[0].Posts, counts[0].Posts
+	for i, dc := range counts {
+		values[i] = dc.Posts
+		if dc.Posts < minPosts {
+			minPosts = dc.Posts
+		}
+		if dc.Posts > maxPosts {
+			maxPosts = dc.Posts
+		}
+	}
+
+	fmt.Printf("%s %s %s  %s %d  %s %d\n",
+		counts[0].Date, sparkline(values), counts[len(counts)-1].Date,
+		c.cyan("min"), minPosts, c.cyan("max"), maxPosts)
+}
+
+// calendarShades go from a day without posts to the busiest quarter
+var calendarShades = []string{"·", "░", "▒", "▓", "█"}
+
+func (c *Commander) showCalendar(year int) {
+	counts, err := c.repo.GetDailyPostCounts(year)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	byDate := make(map[string]int, len(counts))
+	maxPosts, covered, total := 0, 0, 0
+	for _, dc := range counts {
+		byDate[dc.Date] = dc.Posts
+		if dc.Posts > maxPosts {
+			maxPosts = dc.Posts
+		}
+		if dc.Posts > 0 {
+			covered++
+		}
+		total += dc.Posts
+	}
+
+	fmt.Println(c.blue(fmt.Sprintf("\nPosts per day in %d", year)))
+	fmt.Println(strings.Repeat("─", 40))
+	if total == 0 {
+		fmt.Printf("No posts from %d\n", year)
+		return
+	}
+
+	fmt.Print("     ")
+	for day := 1; day <= 31; day++ {
+		if day%5 == 0 {
+			fmt.Printf("%-5d", day)
+		} else if day < 5 {
+			fmt.Print(" ")
+		}
+	}
+	fmt.Println()
+
+	today := time.Now()
+	for month := time.January; month <= time.December; month++ {
+		var row strings.Builder
+		first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+		for d := first; d.Month() == month; d = d.AddDate(0, 0, 1) {
+			// leave the rest of the year blank rather than showing it as a gap
+			if d.After(today) {
+				break
+			}
+			posts := byDate[d.Format("2006-01-02")]
+			shade := 0
+			if posts > 0 {
+				shade = 1 + (posts-1)*(len(calendarShades)-1)/maxPosts
+			}
+			row.WriteString(calendarShades[shade])
+		}
+		fmt.Printf("%s  %s\n", month.String()[:3], row.String())
+	}
+
+	fmt.Println(strings.Repeat("─", 40))
+	fmt.Printf("%s none  %s to %s up to %d posts/day\n",
+		calendarShades[0], calendarShades[1], calendarShades[len(calendarShades)-1], maxPosts)
+	fmt.Printf("%d posts on %d day(s)\n", total, covered)
+}
+
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline scales values between their min and max onto block characters,
+// one per value. a flat series renders at the lowest level.
+func sparkline(values []int) string {
+	if len(values) == 0 {
+		return ""
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		if v < lo {
+			lo = v
+		}
+		if v > hi {
+			hi = v
+		}
+	}
+
+	var b strings.Builder
+	for _, v := range values {
+		level := 0
+		if hi > lo {
+			level = (v - lo) * (len(sparkLevels) - 1) / (hi - lo)
+		}
+		b.WriteRune(sparkLevels[level])
+	}
+	return b.String()
+}
+
+func (c *Commander) showAnomalies(window int) {
+	anomalies, err := c.repo.GetJobAnomalies(window)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	fmt.Println(c.blue(fmt.Sprintf("\nAnomalous Runs (vs the previous %d of the same mode)", window)))
+	fmt.Println(strings.Repeat("─", 70))
+
+	if len(anomalies) == 0 {
+		fmt.Printf("%s No run strays more than two standard deviations from its baseline\n", c.green("✓"))
+		return
+	}
+
+	for _, a := range anomalies {
+		statusColor := c.jobStatusColor(a.Status)
+		fmt.Printf("#%d %s %s %s\n", a.JobID, a.StartedAt.Format("Jan 02 15:04"), a.Mode, statusColor(a.Status))
+		if a.PostsOff {
+			fmt.Printf("  %s %d posts, usually %.0f ± %.1f\n", c.yellow("⚠"), a.Posts, a.PostsMean, a.PostsStdDev)
+		}
+		if a.DurationOff {
+			fmt.Printf("  %s took %s, usually %s ± %s\n", c.yellow("⚠"),
+				a.Duration.Round(time.Second), a.DurationMean.Round(time.Second), a.DurationStdDev.Round(time.Second))
+		}
+	}
+}
+
+// churnUpdateShare is the share of updates across runs above which the
+// interval is probably shorter than it needs to be
+const churnUpdateShare = 0.8
+
+func (c *Commander) showChurn(runs int) {
+	history, err := c.repo.GetScrapingHistory(runs)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	fmt.Println(c.blue("\nNew vs Updated Posts per Run"))
+	fmt.Println(strings.Repeat("─", 60))
+
+	totalNew, totalUpdated, counted := 0, 0, 0
+	for _, job := range history {
+		// plain scrapes don't record details, so there is nothing to split
+		if job.Details == nil {
+			continue
+		}
+		d := job.Details
+		counted++
+		totalNew += d.NewPosts
+		totalUpdated += d.UpdatedPosts
+
+		share := "    -"
+		if seen := d.NewPosts + d.UpdatedPosts; seen > 0 {
+			share = fmt.Sprintf("%4.0f%%", float64(d.NewPosts)/float64(seen)*100)
+		}
+		fmt.Printf("  %s  %-16s %4d new %5d updated  %s new\n",
+			job.StartedAt.Format("Jan 02 15:04"), d.Mode, d.NewPosts, d.UpdatedPosts, share)
+	}
+
+	if counted == 0 {
+		fmt.Println("No runs with details yet, smart scraping modes record them")
+		return
+	}
+
+	fmt.Println(strings.Repeat("─", 60))
+	seen := totalNew + totalUpdated
+	if seen == 0 {
+		fmt.Printf("%d run(s), no posts seen\n", counted)
+		return
+	}
+	updateShare := float64(totalUpdated) / float64(seen)
+	fmt.Printf("%d run(s): %d new, %d updated, %.0f%% new\n", counted, totalNew, totalUpdated, (1-updateShare)*100)
+	if updateShare > churnUpdateShare {
+		fmt.Printf("%s Most posts seen were already stored, a longer interval would lose little\n", c.yellow("⚠"))
+	}
+}
+
+func (c *Commander) showTimings() {
+	stats, err := c.repo.GetDurationStats()
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	fmt.Println(c.blue("\nScrape Durations"))
+	fmt.Println(strings.Repeat("─", 70))
+
+	if len(stats) == 0 || stats[len(stats)-1].Jobs == 0 {
+		fmt.Println("No finished scraping jobs yet")
+		return
+	}
+
+	round := func(d time.Duration) string {
+		return d.Round(100 * time.Millisecond).String()
+	}
+
+	fmt.Printf("%-18s %6s %10s %10s %10s %10s\n", "Mode", "Jobs", "Min", "Avg", "P95", "Max")
+	for _, ds := range stats {
+		mode := ds.Mode
+		if mode == "" {
+			fmt.Println(strings.Repeat("─", 70))
+			mode = "all"
+		}
+		fmt.Printf("%-18s %6d %10s %10s %10s %10s\n",
+			mode, ds.Jobs, round(ds.Min), round(ds.Avg), round(ds.P95), round(ds.Max))
+	}
+
+	// the scheduler runs single scrapes, so compare those against the interval
+	for _, ds := range stats {
+		if ds.Mode == "single" && ds.P95 > c.currentScraper.GetConfig().Interval {
+			fmt.Printf("\n%s p95 of %s exceeds the %s interval of %s, scheduled runs may overlap\n",
+				c.yellow("⚠"), round(ds.P95), c.currentScraperName, c.currentScraper.GetConfig().Interval)
+		}
+	}
+}
+
+func (c *Commander) showReliability(days int) {
+	stats, err := c.repo.GetSuccessRate(days)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	fmt.Println(c.blue(fmt.Sprintf("\nScrape success rate per day (last %d days)", days)))
+	fmt.Println(strings.Repeat("─", 60))
+
+	var total models.DailyJobStats
+	for _, ds := range stats {
+		total.Completed += ds.Completed
+		total.Partial += ds.Partial
+		total.Failed += ds.Failed
+
+		if ds.Finished() == 0 {
+			fmt.Printf("  %s %s\n", ds.Date, c.yellow("⚠ no jobs"))
+			continue
+		}
+
+		rate := ds.SuccessRate()
+		bar := strings.Repeat("█", int(math.Round(rate*30)))
+		label := fmt.Sprintf("%5.1f%%", rate*100)
+		switch {
+		case rate >= 0.9:
+			label = c.green(label)
+		case rate >= 0.5:
+			label = c.yellow(label)
+		default:
+			label = c.red(label)
+		}
+		fmt.Printf("  %s %s %-30s %d ok, %d partial, %d failed\n",
+			ds.Date, label, bar, ds.Completed, ds.Partial, ds.Failed)
+	}
+
+	fmt.Println(strings.Repeat("─", 60))
+	if total.Finished() == 0 {
+		fmt.Println("No finished scraping jobs in this period")
+		return
+	}
+	fmt.Printf("Overall: %.1f%% of %d jobs succeeded (%d partial, %d failed)\n",
+		total.SuccessRate()*100, total.Finished(), total.Partial, total.Failed)
+}
+
+func (c *Commander) showGaps(start, end int) {
+	ranges, err := c.repo.GetMissingIDRanges(start, end)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	fmt.Println(c.blue(fmt.Sprintf("\nMissing IDs %d – %d", start, end)))
+	fmt.Println(strings.Repeat("─", 40))
+
+	if len(ranges) == 0 {
+		fmt.Printf("%s No gaps, every id in the range is stored\n", c.green("✓"))
+		return
+	}
+
+	missing := 0
+	for _, r := range ranges {
+		missing += r.End - r.Start + 1
 	}
-	
-	fmt.Println(c.blue("\nTop 5 Posts by Points:"))
-	if posts, err := c.descriptiveAnalyzer.GetTopPosts(5); err == nil {
-		for i, post := range posts {
-			title := post.Title
-			if len(title) > 50 {
-				title = title[:50] + "..."
-			}
-			fmt.Printf("%d. %s\n   %s (%d points)\n", 
-				i+1, title, post.Author, post.Points)
+
+	const maxShown = 50
+	for i, r := range ranges {
+		if i == maxShown {
+			fmt.Printf("  ... and %d more ranges\n", len(ranges)-maxShown)
+			break
 		}
-	}
-	
-	fmt.Println(c.blue("\nPeak Posting Hours:"))
-	if patterns, err := c.descriptiveAnalyzer.GetPostingPatterns(); err == nil {
-		shown := 0
-		for _, p := range patterns {
-			if shown >= 5 {
-				break
-			}
-			fmt.Printf("  %02d:00 - %d posts (avg %.1f points)\n",
-				p.Hour, p.PostCount, p.AvgPoints)
-			shown++
+		if r.Start == r.End {
+			fmt.Printf("  %d\n", r.Start)
+		} else {
+			fmt.Printf("  %d – %d (%d ids)\n", r.Start, r.End, r.End-r.Start+1)
 		}
 	}
+
+	total := end - start + 1
+	fmt.Printf("\nMissing: %s of %d ids (%.1f%%) in %d ranges\n",
+		c.yellow(fmt.Sprintf("%d", missing)), total, float64(missing)/float64(total)*100, len(ranges))
 }
 
-func (c *Commander) showRecentPosts(limit int) {
-	fmt.Printf(c.blue("\nRecent %d Posts:\n"), limit)
-	fmt.Println(strings.Repeat("─", 70))
-	
-	posts, err := c.repo.GetRecentPosts(limit)
+// checkSource rejects a source that is neither a configured scraper nor
+// found on any stored post, so a typo isn't reported as an empty analysis
+func (c *Commander) checkSource(source string) error {
+	if _, err := config.GetScraper(source); err == nil {
+		return nil
+	}
+	exists, err := c.repo.SourceExists(source)
 	if err != nil {
-		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
-		return
+		return fmt.Errorf("failed to check source %s: %w", source, err)
 	}
-	
-	for _, post := range posts {
-		title := post.Title
-		if len(title) > 60 {
-			title = title[:60] + "..."
-		}
-		
-		fmt.Printf("\n%s %s\n", c.green("+"), title)
-		fmt.Printf("  by %s | %d points | %d comments | %s\n",
-			post.Author, post.Points, post.CommentsCount,
-			post.ScrapedAt.Format("15:04"))
+	if !exists {
+		return fmt.Errorf("unknown source %q (not a configured scraper and no posts from it)", source)
 	}
+	return nil
 }
 
-func (c *Commander) runAnalysis() {
-	fmt.Println(c.blue("\nStatistical Analysis"))
+// runAnalysis prints the analysis report. the error is the first query
+// that failed; sections without enough data are warnings, not errors.
+func (c *Commander) runAnalysis(source string) error {
+	descriptive, inferential := c.descriptiveAnalyzer, c.inferentialAnalyzer
+	if source != "" {
+		descriptive = descriptive.WithSource(source)
+		inferential = inferential.WithSource(source)
+		fmt.Println(c.blue(fmt.Sprintf("\nStatistical Analysis (%s)", source)))
+	} else {
+		fmt.Println(c.blue("\nStatistical Analysis"))
+	}
 	fmt.Println(strings.Repeat("─", 50))
 	
+	var firstErr error
+	fail := func(err error) {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
 	fmt.Println(c.cyan("\nCORRELATION ANALYSIS"))
-	correlations := c.inferentialAnalyzer.CorrelationAnalysis()
+	correlations := inferential.CorrelationAnalysis()
+	if len(correlations) == 0 {
+		fmt.Printf("%s Not enough scored posts for correlation analysis (need %d)\n",
+			c.yellow("⚠"), c.config.App.Analysis.MinSampleSize)
+	}
 	
 	for name, value := range correlations {
 		displayName := strings.ReplaceAll(name, "_", " ")
@@ -389,25 +2337,135 @@ func (c *Commander) runAnalysis() {
 		c.interpretCorrelation(value)
 	}
 	
+	fmt.Println(c.cyan("\nRANK VS ATTENTION"))
+	rank, err := inferential.RankVsPoints()
+	switch {
+	case errors.Is(err, analyzer.ErrUnderPowered):
+		fmt.Printf("%s Not enough ranked posts: %v\n", c.yellow("⚠"), err)
+	case err != nil:
+		fail(err)
+	default:
+		fmt.Println("(rank 1 is the top slot, so negative means higher slots draw more)")
+		fmt.Printf("rank vs points: %.3f\n", rank.Points)
+		c.interpretCorrelation(rank.Points)
+		fmt.Printf("rank vs comments: %.3f\n", rank.Comments)
+		c.interpretCorrelation(rank.Comments)
+	}
+
 	fmt.Println(c.cyan("\nT-TEST ANALYSIS"))
 	
-	if result, err := c.inferentialAnalyzer.WeekdayVsWeekendTTest(); err == nil {
+	if result, err := inferential.WeekdayVsWeekendTTest(); err == nil {
 		fmt.Println("\nWeekday vs Weekend performance:")
 		c.printTTestResult(result)
+	} else {
+		fail(err)
 	}
 	
-	if result, err := c.inferentialAnalyzer.MorningVsEveningTTest(); err == nil {
+	if result, err := inferential.MorningVsEveningTTest(); err == nil {
 		fmt.Println("\nMorning vs Evening performance:")
 		c.printTTestResult(result)
+	} else {
+		fail(err)
 	}
 	
 	fmt.Println(c.cyan("\n7-DAY TREND"))
-	if trends, err := c.descriptiveAnalyzer.GetDailyTrends(7); err == nil {
+	if trends, err := descriptive.GetDailyTrends(7); err == nil {
 		for _, trend := range trends {
 			fmt.Printf("  %s: %d posts, %.1f avg points, %.1f avg comments\n",
 				trend.Date, trend.PostCount, trend.AvgPoints, trend.AvgComments)
 		}
+	} else {
+		fail(fmt.Errorf("failed to load daily trends: %w", err))
+	}
+
+	return firstErr
+}
+
+func (c *Commander) showCorrelation(field1, field2 string) {
+	value, err := c.inferentialAnalyzer.Correlation(field1, field2)
+	if errors.Is(err, analyzer.ErrUnderPowered) {
+		fmt.Printf("%s %s vs %s: %v\n", c.yellow("⚠"), field1, field2, err)
+		return
+	}
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	fmt.Printf("%s vs %s: %.3f\n", field1, field2, value)
+	c.interpretCorrelation(value)
+}
+
+func (c *Commander) compareSources(sourceA, sourceB string) {
+	result, err := c.inferentialAnalyzer.CompareSources(sourceA, sourceB)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	fmt.Printf(c.cyan("\n%s vs %s performance:\n"), sourceA, sourceB)
+	c.printTTestResult(result)
+}
+
+func (c *Commander) compareWindows(recentDays, priorDays int) {
+	result, err := c.inferentialAnalyzer.CompareWindows(recentDays, priorDays)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	fmt.Printf(c.cyan("\nLast %d days vs the %d days before:\n"), recentDays, priorDays)
+	c.printTTestResult(result)
+}
+
+func (c *Commander) showCorrelationMatrix(fields []string) {
+	matrix, err := c.inferentialAnalyzer.CorrelationMatrix(fields)
+	if errors.Is(err, analyzer.ErrUnderPowered) {
+		fmt.Printf("%s %v\n", c.yellow("⚠"), err)
+		return
+	}
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	width := 8
+	for _, field := range fields {
+		if len(field)+1 > width {
+			width = len(field) + 1
+		}
+	}
+
+	fmt.Println(c.blue("\nCorrelation Matrix"))
+	fmt.Println(strings.Repeat("─", width*(len(fields)+1)))
+
+	fmt.Printf("%-*s", width, "")
+	for _, field := range fields {
+		fmt.Printf("%*s", width, field)
+	}
+	fmt.Println()
+
+	threshold := c.config.App.Analysis.CorrelationThreshold
+	for i, field := range fields {
+		fmt.Printf("%-*s", width, field)
+		for j, value := range matrix[i] {
+			// pad before colouring so escape codes don't break alignment
+			cell := fmt.Sprintf("%*.3f", width, value)
+			switch {
+			case i == j:
+				fmt.Print(cell)
+			case value >= threshold:
+				fmt.Print(c.green(cell))
+			case value <= -threshold:
+				fmt.Print(c.red(cell))
+			default:
+				fmt.Print(cell)
+			}
+		}
+		fmt.Println()
 	}
+
+	fmt.Printf("\nHighlighted: |r| >= %.2f\n", threshold)
 }
 
 func (c *Commander) interpretCorrelation(value float64) {
@@ -443,8 +2501,13 @@ func (c *Commander) printTTestResult(result *analyzer.TTestResult) {
 		result.Group1Name, result.Group1Count, result.Group1Mean, result.Group1StdDev)
 	fmt.Printf("  %s: n=%d, mean=%.2f, std=%.2f\n",
 		result.Group2Name, result.Group2Count, result.Group2Mean, result.Group2StdDev)
+	if result.UnderPowered {
+		fmt.Printf("  Result: %s\n", c.yellow(result.Interpretation))
+		return
+	}
 	fmt.Printf("  T-test: %.3f\n", result.TStatistic)
 	fmt.Printf("  Degrees of freedom: %.1f\n", result.DegreesOfFreedom)
+	fmt.Printf("  P-value: %.4f\n", result.PValue)
 	
 	if result.Significant {
 		fmt.Printf("  Result: %s\n", c.green(result.Interpretation))
@@ -453,29 +2516,148 @@ func (c *Commander) printTTestResult(result *analyzer.TTestResult) {
 	}
 }
 
-func (c *Commander) exportData() {
-	exportPath := c.config.App.ExportPath
-	if exportPath == "" {
-		exportPath = "./exports"
+// newExporter returns an exporter writing to the configured export_path,
+// which may be a directory or a destination URL such as s3://bucket/prefix
+func (c *Commander) newExporter() (*Exporter, error) {
+	destination := c.config.App.ExportPath
+	if destination == "" {
+		destination = "./exports"
 	}
-	
-	if err := os.MkdirAll(exportPath, 0755); err != nil {
-		fmt.Printf("%s Failed to create export directory: %v\n", c.red("✗"), err)
-		return
+	sink, err := NewSink(destination)
+	if err != nil {
+		return nil, err
 	}
-	
+
 	exporter := NewExporter(c.repo)
-	filename, err := exporter.ExportToCSV()
+	exporter.SetSink(sink)
+	return exporter, nil
+}
+
+func (c *Commander) exportPostHistory(hnID int) {
+	exporter, err := c.newExporter()
 	if err != nil {
 		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
 		return
 	}
-	
-	newPath := fmt.Sprintf("%s/%s", exportPath, filename)
-	if err := os.Rename(filename, newPath); err == nil {
-		filename = newPath
+
+	filename, err := exporter.ExportPostHistoryJSON(hnID)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+	fmt.Printf("%s Exported history of post %d to %s\n", c.green("✓"), hnID, filename)
+}
+
+func (c *Commander) reclassify(processors []string) {
+	fmt.Printf(c.cyan("Reclassifying stored posts with %s...\n"), strings.Join(processors, ", "))
+
+	result, err := scraper.Reclassify(c.repo, processors)
+	if result != nil && result.Checked > 0 {
+		fmt.Printf("Checked %d posts, updated %d\n", result.Checked, result.Updated)
+		if result.Failed > 0 {
+			fmt.Printf("%s %d posts had a processor fail, see the log\n", c.yellow("⚠"), result.Failed)
+		}
+	}
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+	fmt.Printf("%s Reclassification finished\n", c.green("✓"))
+}
+
+func (c *Commander) backup(path string, withHistory bool) {
+	file, err := os.Create(path)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+	defer file.Close()
+
+	stats, err := database.Backup(file, withHistory)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	if withHistory {
+		fmt.Printf("%s Backed up %d posts and %d history rows to %s\n", c.green("✓"), stats.Posts, stats.History, path)
+	} else {
+		fmt.Printf("%s Backed up %d posts to %s\n", c.green("✓"), stats.Posts, path)
+	}
+}
+
+func (c *Commander) restore(path string) {
+	file, err := os.Open(path)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+	defer file.Close()
+
+	fmt.Println(c.cyan(fmt.Sprintf("Restoring %s...", path)))
+	stats, err := database.Restore(file)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		fmt.Println("  Nothing was restored")
+		return
+	}
+	fmt.Printf("%s Restored %d posts and %d history rows\n", c.green("✓"), stats.Posts, stats.History)
+}
+
+func (c *Commander) exportSearch(terms []string, asJSON bool) {
+	exporter, err := c.newExporter()
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+	if err := exporter.SetColumns(c.config.App.ExportColumns); err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	filename, count, err := exporter.ExportSearch(terms, asJSON)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	query := strings.Join(terms, " ")
+	if count == 0 {
+		fmt.Printf("%s No titles match %q, wrote an empty export to %s\n", c.yellow("⚠"), query, filename)
+		return
+	}
+	fmt.Printf("%s Exported %d posts matching %q to %s\n", c.green("✓"), count, query, filename)
+}
+
+func (c *Commander) exportData(args []string) error {
+	exporter, err := c.newExporter()
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return err
+	}
+
+	columns := c.config.App.ExportColumns
+	compressed := false
+	for _, arg := range args {
+		if arg == "--gzip" {
+			compressed = true
+			continue
+		}
+		columns = strings.Split(arg, ",")
+	}
+	exporter.SetGzip(compressed)
+	if err := exporter.SetColumns(columns); err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return err
+	}
+
+	filename, err := exporter.ExportToCSV()
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return err
 	}
 	
+	// only local exports can be measured
 	if info, err := os.Stat(filename); err == nil {
 		size := info.Size()
 		sizeStr := fmt.Sprintf("%d bytes", size)
@@ -484,10 +2666,183 @@ func (c *Commander) exportData() {
 		} else if size > 1024 {
 			sizeStr = fmt.Sprintf("%.2f KB", float64(size)/1024)
 		}
+		if compressed {
+			sizeStr += " compressed"
+		}
 		fmt.Printf("%s Exported data to %s (%s)\n", c.green("✓"), filename, sizeStr)
 	} else {
 		fmt.Printf("%s Exported data to %s\n", c.green("✓"), filename)
 	}
+	return nil
+}
+
+func (c *Commander) importData(path string) {
+	importer := NewImporter(c.repo)
+	result, err := importer.ImportJSONL(path)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		if result != nil {
+			fmt.Printf("  Inserted before failure: %d\n", result.Inserted)
+		}
+		return
+	}
+
+	fmt.Printf("%s Imported %s from %s\n", c.green("✓"), path, c.green(fmt.Sprintf("%d new posts", result.Inserted)))
+	fmt.Printf("  Skipped (already stored): %d\n", result.Skipped)
+	if result.Malformed > 0 {
+		fmt.Printf("  Malformed lines:          %s\n", c.yellow(fmt.Sprintf("%d", result.Malformed)))
+	}
+}
+
+func (c *Commander) mergeAuthors(from, to string) {
+	if from == to {
+		fmt.Printf("%s Source and target author are the same\n", c.yellow("⚠"))
+		return
+	}
+
+	merged, err := c.repo.MergeAuthors(from, to)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+	fmt.Printf("%s Moved %d posts from %s to %s\n", c.green("✓"), merged, from, to)
+}
+
+func (c *Commander) resetSchema(force bool) {
+	// dedicated scraper tables don't depend on posts, so they are listed
+	// explicitly or they would keep their data
+	var postsTables []string
+	for _, sc := range c.config.Scrapers {
+		postsTables = append(postsTables, sc.Table)
+	}
+	tables, err := database.ResetTables(postsTables)
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	if !database.IsLocal() {
+		fmt.Printf("%s Refusing to reset a non-local database (%s)\n", c.yellow("⚠"), database.ConnectedHost())
+		fmt.Printf("  If you really mean it, drop the tables from psql: DROP TABLE %s CASCADE;\n",
+			strings.Join(tables, ", "))
+		return
+	}
+
+	if !force {
+		fmt.Printf("%s This drops %s and all of their data\n", c.yellow("⚠"), strings.Join(tables, ", "))
+		fmt.Println("  Run 'reset --force' to continue")
+		return
+	}
+
+	if active := c.scheduler.GetActiveScrapers(); len(active) > 0 {
+		fmt.Printf("%s Stop the running scrapers first: %s\n", c.red("✗"), strings.Join(active, ", "))
+		return
+	}
+
+	fmt.Print(c.yellow("Type 'yes' to drop all tables: "))
+	if c.readAnswer() != "yes" {
+		fmt.Println("Reset cancelled")
+		return
+	}
+
+	if err := database.ResetSchema(postsTables); err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+	fmt.Printf("%s Schema recreated, database is empty\n", c.green("✓"))
+}
+
+func (c *Commander) showConfig(format string) {
+	redacted := c.config.Redacted()
+
+	var out []byte
+	var err error
+	switch format {
+	case "yaml":
+		out, err = yaml.Marshal(redacted)
+	case "json":
+		out, err = json.MarshalIndent(redacted, "", "  ")
+	default:
+		fmt.Printf("%s Usage: config [yaml|json]\n", c.red("✗"))
+		return
+	}
+	if err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+
+	fmt.Println(c.blue("\nEffective Configuration"))
+	fmt.Println(strings.Repeat("─", 50))
+	if path := config.LoadedPath(); path != "" {
+		fmt.Printf("Loaded from: %s\n", path)
+	} else {
+		fmt.Printf("Loaded from: %s\n", c.yellow("built-in defaults (no config file loaded)"))
+	}
+
+	// main applies these on top of the config when building the DSN
+	var overrides []string
+	for _, key := range []string{"DB_HOST", "DB_USER", "DB_PASSWORD", "DB_NAME"} {
+		if os.Getenv(key) != "" {
+			overrides = append(overrides, key)
+		}
+	}
+	if len(overrides) > 0 {
+		fmt.Printf("Env overrides: %s\n", strings.Join(overrides, ", "))
+		if c.config.Database.URL != "" {
+			fmt.Println(c.yellow("  (ignored, database.url is set)"))
+		}
+	}
+
+	fmt.Println()
+	fmt.Println(string(out))
+}
+
+func (c *Commander) exportConfig(path string) {
+	if err := config.ExportJSON(path); err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+	fmt.Printf("%s Configuration written to %s (credentials redacted)\n", c.green("✓"), path)
+}
+
+func (c *Commander) importConfig(path string) {
+	if err := config.ImportJSON(path); err != nil {
+		fmt.Printf("%s Error: %v\n", c.red("✗"), err)
+		return
+	}
+	c.config = config.Get()
+
+	fmt.Printf("%s Configuration loaded from %s (%d scrapers)\n", c.green("✓"), path, len(c.config.Scrapers))
+	c.rebuildFromConfig()
+	if err := c.scheduler.ReloadScrapers(); err != nil {
+		fmt.Printf("%s %v\n", c.yellow("⚠"), err)
+	}
+	// the pool, colors and scheduler limits were built from the old config
+	fmt.Printf("%s Database settings, colors and scheduler limits keep their current values until restart\n", c.yellow("⚠"))
+}
+
+// rebuildFromConfig replaces the scraper and analyzers built from the
+// previous config. when the current scraper is gone from the new one it
+// falls back to the default scraper.
+func (c *Commander) rebuildFromConfig() {
+	name := c.currentScraperName
+	scraperInstance, err := scraper.NewGenericScraper(c.repo, name)
+	if err != nil {
+		name = c.config.App.DefaultScraper
+		scraperInstance, err = scraper.NewGenericScraper(c.repo, name)
+	}
+	if err != nil {
+		fmt.Printf("%s Keeping scraper %s with its previous config: %v\n", c.yellow("⚠"), c.currentScraperName, err)
+	} else {
+		if name != c.currentScraperName {
+			fmt.Printf("%s Scraper %s is not in the new config, switched to %s\n", c.yellow("⚠"), c.currentScraperName, name)
+		}
+		c.currentScraper = scraperInstance
+		c.currentScraperName = name
+	}
+
+	c.descriptiveAnalyzer = analyzer.NewDescriptiveAnalyzer(c.repo)
+	c.inferentialAnalyzer = analyzer.NewInferentialAnalyzer(c.repo)
 }
 
 func (c *Commander) listScrapers() {
@@ -533,10 +2888,18 @@ func (c *Commander) quit() {
 	if activeScrapers := c.scheduler.GetActiveScrapers(); len(activeScrapers) > 0 {
 		fmt.Println("Stopping active scrapers...")
 		for _, name := range activeScrapers {
-			c.scheduler.StopScraper(name)
-			fmt.Printf("  Stopped %s\n", name)
+			fmt.Printf("  Stopping %s\n", name)
 		}
 	}
+	// a hung scrape is cancelled, and never holds up exit for long
+	if !c.scheduler.Shutdown(quitGracePeriod) {
+		fmt.Printf("%s Some scrapes were still running after %s, exiting anyway\n", c.yellow("⚠"), quitGracePeriod)
+	}
+	// Shutdown flushed once already; a scrape still running past the grace
+	// period may have queued more since
+	if err := scraper.FlushHistory(); err != nil {
+		fmt.Printf("%s %v\n", c.yellow("⚠"), err)
+	}
 	
 	fmt.Printf("%s Goodbye!\n", c.green("✓"))
 	os.Exit(0)
@@ -1,46 +1,156 @@
 package cli
 
 import (
+	"compress/gzip"
 	"encoding/csv"
+	"encoding/json"
 	"fmt"
-	"os"
+	"io"
+	"net/url"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/dzmitry-papkou/scraper/internal/database"
+	"github.com/dzmitry-papkou/scraper/internal/models"
 )
 
+type exportColumn struct {
+	header string
+	value  func(p *models.Post) string
+}
+
+var exportColumns = map[string]exportColumn{
+	"id":         {"ID", func(p *models.Post) string { return strconv.Itoa(p.ID) }},
+	"hn_id":      {"HN_ID", func(p *models.Post) string { return strconv.Itoa(p.HnID) }},
+	"title":      {"Title", func(p *models.Post) string { return p.Title }},
+	"url":        {"URL", func(p *models.Post) string { return p.URL }},
+	"domain":     {"Domain", func(p *models.Post) string { return extractDomain(p.URL) }},
+	"author":     {"Author", func(p *models.Post) string { return p.Author }},
+	"points":     {"Points", func(p *models.Post) string { return strconv.Itoa(p.Points) }},
+	"comments":   {"Comments", func(p *models.Post) string { return strconv.Itoa(p.CommentsCount) }},
+	"post_time":  {"PostTime", func(p *models.Post) string { return formatPostTime(p.PostTime, time.RFC3339) }},
+	"scraped_at": {"ScrapedAt", func(p *models.Post) string { return p.ScrapedAt.Format(time.RFC3339) }},
+}
+
+var defaultExportColumns = []string{
+	"id", "hn_id", "title", "url", "author",
+	"points", "comments", "post_time", "scraped_at",
+}
+
 type Exporter struct {
-	repo *database.Repository
+	repo    *database.Repository
+	columns []string
+	gzip    bool
+	sink    Sink
 }
 
 func NewExporter(repo *database.Repository) *Exporter {
 	return &Exporter{
-		repo: repo,
+		repo:    repo,
+		columns: defaultExportColumns,
+		sink:    LocalSink{},
 	}
 }
 
-func (e *Exporter) ExportToCSV() (string, error) {
-	filename := fmt.Sprintf("hn_export_%s.csv", time.Now().Format("20060102_150405"))
-	
-	file, err := os.Create(filename)
+// SetSink changes where exports are written, the current directory by default
+func (e *Exporter) SetSink(sink Sink) {
+	e.sink = sink
+}
+
+// create opens name on the sink. the returned close reports the sink's
+// error, since remote sinks only upload once the writer is closed.
+func (e *Exporter) create(name string) (io.Writer, func() error, error) {
+	w, err := e.sink.Create(name)
 	if err != nil {
-		return "", fmt.Errorf("failed to create file: %w", err)
+		return nil, nil, err
 	}
-	defer file.Close()
+	return w, func() error {
+		if err := w.Close(); err != nil {
+			return fmt.Errorf("failed to write %s: %w", e.sink.Location(name), err)
+		}
+		return nil
+	}, nil
+}
+
+// SetColumns selects and orders the exported columns; an empty list
+// restores the default full set
+func (e *Exporter) SetColumns(columns []string) error {
+	if len(columns) == 0 {
+		e.columns = defaultExportColumns
+		return nil
+	}
+
+	selected := make([]string, 0, len(columns))
+	for _, column := range columns {
+		name := strings.ToLower(strings.TrimSpace(column))
+		if _, ok := exportColumns[name]; !ok {
+			return fmt.Errorf("unknown export column %q (available: %s)", column, strings.Join(ExportColumnNames(), ", "))
+		}
+		selected = append(selected, name)
+	}
+
+	e.columns = selected
+	return nil
+}
+
+// SetGzip compresses the export on the fly and adds a .gz suffix
+func (e *Exporter) SetGzip(enabled bool) {
+	e.gzip = enabled
+}
+
+func ExportColumnNames() []string {
+	names := make([]string, 0, len(exportColumns))
+	for name := range exportColumns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
 
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
+func (e *Exporter) ExportToCSV() (filename string, err error) {
+	filename = fmt.Sprintf("hn_export_%s.csv", time.Now().Format("20060102_150405"))
+	if e.gzip {
+		filename += ".gz"
+	}
+	
+	file, closeFile, err := e.create(filename)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if closeErr := closeFile(); closeErr != nil && err == nil {
+			filename, err = "", closeErr
+		}
+	}()
 
-	header := []string{
-		"ID", "HN_ID", "Title", "URL", "Author", 
-		"Points", "Comments", "PostTime", "ScrapedAt",
+	var out io.Writer = file
+	if e.gzip {
+		gz := gzip.NewWriter(file)
+		// the gzip footer is only written on Close, so its error matters
+		defer func() {
+			if closeErr := gz.Close(); closeErr != nil && err == nil {
+				err = fmt.Errorf("failed to finish gzip stream: %w", closeErr)
+			}
+		}()
+		out = gz
 	}
-	if err := writer.Write(header); err != nil {
+
+	writer := csv.NewWriter(out)
+	// flush before the gzip and file closers above run
+	defer func() {
+		writer.Flush()
+		if flushErr := writer.Error(); flushErr != nil && err == nil {
+			err = fmt.Errorf("failed to write records: %w", flushErr)
+		}
+	}()
+
+	if err := writer.Write(e.header()); err != nil {
 		return "", fmt.Errorf("failed to write header: %w", err)
 	}
 
-	db := database.GetDB()
+	db := database.GetQuerier()
 	query := `
 		SELECT id, hn_id, title, url, author, points, comments_count, post_time, scraped_at
 		FROM posts
@@ -54,32 +164,171 @@ func (e *Exporter) ExportToCSV() (string, error) {
 
 	count := 0
 	for rows.Next() {
-		var id, hnID, points, comments int
-		var title, url, author string
-		var postTime, scrapedAt time.Time
+		var p models.Post
 
-		err := rows.Scan(&id, &hnID, &title, &url, &author, &points, &comments, &postTime, &scrapedAt)
+		err := rows.Scan(&p.ID, &p.HnID, &p.Title, &p.URL, &p.Author,
+			&p.Points, &p.CommentsCount, database.NullTime(&p.PostTime), &p.ScrapedAt)
 		if err != nil {
 			continue
 		}
 
-		record := []string{
-			strconv.Itoa(id),
-			strconv.Itoa(hnID),
-			title,
-			url,
-			author,
-			strconv.Itoa(points),
-			strconv.Itoa(comments),
-			postTime.Format(time.RFC3339),
-			scrapedAt.Format(time.RFC3339),
-		}
-
-		if err := writer.Write(record); err != nil {
+		if err := writer.Write(e.record(&p)); err != nil {
 			return "", fmt.Errorf("failed to write record: %w", err)
 		}
 		count++
 	}
 
-	return filename, nil
-}
\ No newline at end of file
+	return e.sink.Location(filename), nil
+}
+
+// WritePostsCSV writes already-loaded posts with the selected columns,
+// without touching the database
+func (e *Exporter) WritePostsCSV(w io.Writer, posts []models.Post) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(e.header()); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	for i := range posts {
+		if err := writer.Write(e.record(&posts[i])); err != nil {
+			return fmt.Errorf("failed to write record: %w", err)
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func (e *Exporter) header() []string {
+	header := make([]string, len(e.columns))
+	for i, name := range e.columns {
+		header[i] = exportColumns[name].header
+	}
+	return header
+}
+
+func (e *Exporter) record(p *models.Post) []string {
+	record := make([]string, len(e.columns))
+	for i, name := range e.columns {
+		record[i] = exportColumns[name].value(p)
+	}
+	return record
+}
+
+// formatPostTime formats t, or returns "" for a post stored without a time
+func formatPostTime(t time.Time, layout string) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(layout)
+}
+
+func extractDomain(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(u.Hostname(), "www.")
+}
+
+type postHistoryExport struct {
+	Post    models.Post        `json:"post"`
+	History []postHistoryPoint `json:"history"`
+}
+
+type postHistoryPoint struct {
+	RecordedAt    time.Time `json:"recorded_at"`
+	Points        int       `json:"points"`
+	CommentsCount int       `json:"comments_count"`
+	// hours between submission and the snapshot, for plotting against age
+	HoursSincePost float64 `json:"hours_since_post"`
+}
+
+// ExportPostHistoryJSON writes one post and its score history to a JSON file
+func (e *Exporter) ExportPostHistoryJSON(hnID int) (location string, err error) {
+	post, err := e.repo.GetPostByHnID(hnID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get post: %w", err)
+	}
+	if post == nil {
+		return "", fmt.Errorf("post %d not found", hnID)
+	}
+
+	history, err := e.repo.GetPostHistory(post.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get history: %w", err)
+	}
+	if len(history) == 0 {
+		return "", fmt.Errorf("post %d has no recorded history", hnID)
+	}
+
+	export := postHistoryExport{Post: *post}
+	for _, h := range history {
+		export.History = append(export.History, postHistoryPoint{
+			RecordedAt:     h.RecordedAt,
+			Points:         h.Points,
+			CommentsCount:  h.CommentsCount,
+			HoursSincePost: h.RecordedAt.Sub(post.PostTime).Hours(),
+		})
+	}
+
+	filename := fmt.Sprintf("hn_history_%d_%s.json", hnID, time.Now().Format("20060102_150405"))
+	file, closeFile, err := e.create(filename)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if closeErr := closeFile(); closeErr != nil && err == nil {
+			location, err = "", closeErr
+		}
+	}()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(export); err != nil {
+		return "", fmt.Errorf("failed to write history: %w", err)
+	}
+
+	return e.sink.Location(filename), nil
+}
+
+// ExportSearch writes the posts whose titles match every term, as CSV with
+// the selected columns or as JSON, and returns how many matched
+func (e *Exporter) ExportSearch(terms []string, asJSON bool) (filename string, count int, err error) {
+	posts, err := e.repo.SearchPosts(terms)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to search posts: %w", err)
+	}
+
+	ext := "csv"
+	if asJSON {
+		ext = "json"
+	}
+	filename = fmt.Sprintf("hn_search_%s.%s", time.Now().Format("20060102_150405"), ext)
+
+	file, closeFile, err := e.create(filename)
+	if err != nil {
+		return "", 0, err
+	}
+	defer func() {
+		if closeErr := closeFile(); closeErr != nil && err == nil {
+			filename, count, err = "", 0, closeErr
+		}
+	}()
+
+	if asJSON {
+		// an empty result is still a valid, empty array
+		if posts == nil {
+			posts = []models.Post{}
+		}
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(posts); err != nil {
+			return "", 0, fmt.Errorf("failed to write posts: %w", err)
+		}
+	} else if err := e.WritePostsCSV(file, posts); err != nil {
+		return "", 0, err
+	}
+
+	return e.sink.Location(filename), len(posts), nil
+}
@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/dzmitry-papkou/scraper/internal/database"
+	"github.com/dzmitry-papkou/scraper/internal/models"
+)
+
+type Importer struct {
+	repo *database.Repository
+}
+
+type ImportResult struct {
+	Lines     int
+	Inserted  int
+	Skipped   int
+	Malformed int
+}
+
+func NewImporter(repo *database.Repository) *Importer {
+	return &Importer{
+		repo: repo,
+	}
+}
+
+// ImportJSONL reads one models.Post JSON object per line; malformed or
+// incomplete lines are logged and skipped, posts already stored are kept.
+// a post without post_time is stored with it NULL rather than a made-up time.
+func (i *Importer) ImportJSONL(path string) (*ImportResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	result := &ImportResult{}
+	var posts []models.Post
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		result.Lines++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var post models.Post
+		if err := json.Unmarshal([]byte(line), &post); err != nil {
+			log.Printf("Warning: line %d: malformed JSON: %v", result.Lines, err)
+			result.Malformed++
+			continue
+		}
+		if post.HnID <= 0 || strings.TrimSpace(post.Title) == "" {
+			log.Printf("Warning: line %d: missing hn_id or title", result.Lines)
+			result.Malformed++
+			continue
+		}
+
+		if post.Author == "" {
+			post.Author = "unknown"
+		}
+		posts = append(posts, post)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	inserted, err := i.repo.InsertPostsBatch(posts)
+	result.Inserted = inserted
+	result.Skipped = len(posts) - inserted
+	if err != nil {
+		return result, fmt.Errorf("failed to insert posts: %w", err)
+	}
+
+	return result, nil
+}
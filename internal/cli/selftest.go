@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dzmitry-papkou/scraper/internal/scraper"
+	"github.com/fatih/color"
+)
+
+// RunSelfTest checks the parser against the live site and prints a
+// PASS/FAIL report. it needs no database, so batch mode can call it
+// before connecting.
+func RunSelfTest(s *scraper.Scraper) bool {
+	green := color.New(color.FgGreen).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+	blue := color.New(color.FgBlue).SprintFunc()
+
+	fmt.Println(blue("\nParser Self-Test"))
+	fmt.Println(strings.Repeat("─", 50))
+
+	report, err := s.Verify()
+	fmt.Printf("Page: %s\n", report.URL)
+	if report.SavedHTML != "" {
+		defer fmt.Printf("Page HTML saved to %s\n", report.SavedHTML)
+	}
+	if err != nil {
+		fmt.Printf("%s FAIL: %v\n", red("✗"), err)
+		return false
+	}
+
+	for _, check := range report.Checks {
+		if check.Passed {
+			fmt.Printf("  %s %-12s %s\n", green("PASS"), check.Name, check.Detail)
+		} else {
+			fmt.Printf("  %s %-12s %s\n", red("FAIL"), check.Name, check.Detail)
+		}
+	}
+
+	if report.Passed() {
+		fmt.Printf("\n%s Parser looks healthy\n", green("✓"))
+		return true
+	}
+	fmt.Printf("\n%s Parser check failed, the page markup may have changed\n", red("✗"))
+	return false
+}
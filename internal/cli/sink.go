@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Sink is where exports are written to. Location describes a created
+// name for messages, e.g. the full path of a local file.
+type Sink interface {
+	Create(name string) (io.WriteCloser, error)
+	Location(name string) string
+}
+
+// LocalSink writes exports as files under Dir, creating it on first use
+type LocalSink struct {
+	Dir string
+}
+
+func (s LocalSink) Create(name string) (io.WriteCloser, error) {
+	if s.Dir != "" {
+		if err := os.MkdirAll(s.Dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create export directory: %w", err)
+		}
+	}
+	file, err := os.Create(s.Location(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %w", err)
+	}
+	return file, nil
+}
+
+func (s LocalSink) Location(name string) string {
+	return filepath.Join(s.Dir, name)
+}
+
+// s3PutObjectAPI is the part of the S3 client S3Sink uses
+type s3PutObjectAPI interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
+
+// S3Sink uploads exports as objects under Prefix in Bucket. credentials and
+// region come from the usual AWS environment, shared config and profile.
+type S3Sink struct {
+	Bucket string
+	Prefix string
+	client s3PutObjectAPI
+}
+
+func NewS3Sink(bucket, prefix string) (*S3Sink, error) {
+	awsConfig, err := awsconfig.LoadDefaultConfig(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	return &S3Sink{
+		Bucket: bucket,
+		Prefix: strings.Trim(prefix, "/"),
+		client: s3.NewFromConfig(awsConfig),
+	}, nil
+}
+
+func (s *S3Sink) key(name string) string {
+	if s.Prefix == "" {
+		return name
+	}
+	return path.Join(s.Prefix, name)
+}
+
+// Create buffers the export in memory; the object is uploaded when the
+// writer is closed, and Close reports a failed upload
+func (s *S3Sink) Create(name string) (io.WriteCloser, error) {
+	return &s3Object{sink: s, key: s.key(name)}, nil
+}
+
+func (s *S3Sink) Location(name string) string {
+	return "s3://" + s.Bucket + "/" + s.key(name)
+}
+
+type s3Object struct {
+	sink   *S3Sink
+	key    string
+	buf    bytes.Buffer
+	closed bool
+}
+
+func (o *s3Object) Write(p []byte) (int, error) {
+	if o.closed {
+		return 0, fmt.Errorf("write to closed export s3://%s/%s", o.sink.Bucket, o.key)
+	}
+	return o.buf.Write(p)
+}
+
+func (o *s3Object) Close() error {
+	if o.closed {
+		return nil
+	}
+	o.closed = true
+
+	_, err := o.sink.client.PutObject(context.Background(), &s3.PutObjectInput{
+		Bucket:        aws.String(o.sink.Bucket),
+		Key:           aws.String(o.key),
+		Body:          bytes.NewReader(o.buf.Bytes()),
+		ContentLength: aws.Int64(int64(o.buf.Len())),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload to s3://%s/%s: %w", o.sink.Bucket, o.key, err)
+	}
+	return nil
+}
+
+// NewSink picks the sink for an export destination. a plain path or a
+// file:// URL is a local directory, and s3://bucket/prefix uploads to S3.
+func NewSink(destination string) (Sink, error) {
+	if !strings.Contains(destination, "://") {
+		return LocalSink{Dir: destination}, nil
+	}
+
+	u, err := url.Parse(destination)
+	if err != nil {
+		return nil, fmt.Errorf("invalid export destination %q: %w", destination, err)
+	}
+
+	switch u.Scheme {
+	case "file":
+		return LocalSink{Dir: u.Host + u.Path}, nil
+	case "s3":
+		if u.Host == "" {
+			return nil, fmt.Errorf("export destination %q has no bucket", destination)
+		}
+		return NewS3Sink(u.Host, u.Path)
+	default:
+		return nil, fmt.Errorf("unsupported export destination scheme %q", u.Scheme)
+	}
+}
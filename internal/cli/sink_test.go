@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+type fakeS3 struct {
+	objects map[string]string
+	err     error
+}
+
+func (f *fakeS3) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	body, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+	if n := aws.ToInt64(params.ContentLength); n != int64(len(body)) {
+		return nil, fmt.Errorf("content length %d, body is %d bytes", n, len(body))
+	}
+	f.objects[aws.ToString(params.Bucket)+"/"+aws.ToString(params.Key)] = string(body)
+	return &s3.PutObjectOutput{}, nil
+}
+
+func TestNewSink(t *testing.T) {
+	tests := []struct {
+		destination string
+		want        string
+		wantErr     bool
+	}{
+		{"./exports", "exports/a.csv", false},
+		{"file:///tmp/exports", "/tmp/exports/a.csv", false},
+		{"s3://bucket/daily/", "s3://bucket/daily/a.csv", false},
+		{"s3://bucket", "s3://bucket/a.csv", false},
+		{"s3:///prefix", "", true},
+		{"ftp://host/dir", "", true},
+	}
+	for _, tt := range tests {
+		sink, err := NewSink(tt.destination)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NewSink(%q) error = %v, wantErr %v", tt.destination, err, tt.wantErr)
+			continue
+		}
+		if err == nil {
+			if got := sink.Location("a.csv"); got != tt.want {
+				t.Errorf("NewSink(%q).Location = %q, want %q", tt.destination, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestS3SinkUploadsOnClose(t *testing.T) {
+	fake := &fakeS3{objects: map[string]string{}}
+	sink := &S3Sink{Bucket: "bucket", Prefix: "daily", client: fake}
+
+	w, err := sink.Create("posts.csv")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	fmt.Fprint(w, "id,title\n")
+	fmt.Fprint(w, "1,hello\n")
+	if len(fake.objects) != 0 {
+		t.Error("object uploaded before the writer was closed")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if got, want := fake.objects["bucket/daily/posts.csv"], "id,title\n1,hello\n"; got != want {
+		t.Errorf("uploaded %q, want %q", got, want)
+	}
+}
+
+func TestS3SinkReportsUploadError(t *testing.T) {
+	sink := &S3Sink{Bucket: "bucket", client: &fakeS3{err: errors.New("access denied")}}
+
+	w, err := sink.Create("posts.csv")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	fmt.Fprint(w, "id\n")
+	if err := w.Close(); err == nil {
+		t.Error("Close succeeded although the upload failed")
+	}
+}
@@ -0,0 +1,204 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+)
+
+// ExportJSON writes the running config to path as JSON, with database
+// credentials and auth headers redacted so the file can be shared.
+// durations are written as strings such as "5m0s".
+func ExportJSON(path string) error {
+	data, err := json.MarshalIndent(Get().Redacted(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// ImportJSON replaces the running config with the one in path. the new
+// config gets the same defaults and validation as a YAML file, and is only
+// swapped in when both pass. credentials redacted by ExportJSON are taken
+// from the running config, so an exported file imports cleanly.
+func ImportJSON(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	imported := &Config{}
+	if err := decodeStrict(data, imported); err != nil {
+		return fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	restoreRedacted(imported, Get())
+	setDefaults(imported)
+
+	if err := Validate(imported); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	warnDedicatedTables(imported)
+	set(imported, path)
+	return nil
+}
+
+// restoreRedacted puts back the password and scraper headers from current
+// wherever imported still carries the redaction mask
+func restoreRedacted(imported, current *Config) {
+	if imported.Database.Password == redactedPassword {
+		imported.Database.Password = current.Database.Password
+	}
+
+	for i := range imported.Scrapers {
+		scraper := &imported.Scrapers[i]
+		for name, value := range scraper.Headers {
+			if value != redactedPassword || !sensitiveHeader(name) {
+				continue
+			}
+			// a masked value with nothing to restore is dropped rather than sent
+			delete(scraper.Headers, name)
+			for _, existing := range current.Scrapers {
+				if original, ok := existing.Headers[name]; ok && existing.Name == scraper.Name {
+					scraper.Headers[name] = original
+				}
+			}
+		}
+	}
+
+	u, err := url.Parse(imported.Database.URL)
+	if err != nil || u.User == nil {
+		return
+	}
+	if password, ok := u.User.Password(); !ok || password != redactedPassword {
+		return
+	}
+	currentURL, err := url.Parse(current.Database.URL)
+	if err != nil || currentURL.User == nil {
+		return
+	}
+	if password, ok := currentURL.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), password)
+		imported.Database.URL = u.String()
+	}
+}
+
+// decodeStrict is json.Unmarshal rejecting unknown fields, which the
+// custom unmarshalers below would otherwise let through
+func decodeStrict(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// jsonDuration is a time.Duration written as a string like "5m0s". the
+// nanosecond numbers of older exports are still read.
+type jsonDuration time.Duration
+
+func (d jsonDuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *jsonDuration) UnmarshalJSON(data []byte) error {
+	var nanos int64
+	if err := json.Unmarshal(data, &nanos); err == nil {
+		*d = jsonDuration(nanos)
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid duration %s", data)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q (use e.g. 30s, 5m, 1h)", s)
+	}
+	*d = jsonDuration(parsed)
+	return nil
+}
+
+// the config structs holding durations swap them for jsonDuration fields,
+// which take precedence over the embedded ones of the same name
+
+func (c DatabaseConfig) MarshalJSON() ([]byte, error) {
+	type plain DatabaseConfig
+	return json.Marshal(struct {
+		plain
+		ConnectionLifetime jsonDuration `json:"connection_lifetime"`
+		StatementTimeout   jsonDuration `json:"statement_timeout"`
+		ConnectTimeout     jsonDuration `json:"connect_timeout,omitempty"`
+	}{plain(c), jsonDuration(c.ConnectionLifetime), jsonDuration(c.StatementTimeout), jsonDuration(c.ConnectTimeout)})
+}
+
+func (c *DatabaseConfig) UnmarshalJSON(data []byte) error {
+	type plain DatabaseConfig
+	aux := struct {
+		*plain
+		ConnectionLifetime jsonDuration `json:"connection_lifetime"`
+		StatementTimeout   jsonDuration `json:"statement_timeout"`
+		ConnectTimeout     jsonDuration `json:"connect_timeout,omitempty"`
+	}{(*plain)(c), jsonDuration(c.ConnectionLifetime), jsonDuration(c.StatementTimeout), jsonDuration(c.ConnectTimeout)}
+	if err := decodeStrict(data, &aux); err != nil {
+		return err
+	}
+	c.ConnectionLifetime = time.Duration(aux.ConnectionLifetime)
+	c.StatementTimeout = time.Duration(aux.StatementTimeout)
+	c.ConnectTimeout = time.Duration(aux.ConnectTimeout)
+	return nil
+}
+
+func (c ScraperConfig) MarshalJSON() ([]byte, error) {
+	type plain ScraperConfig
+	return json.Marshal(struct {
+		plain
+		Interval    jsonDuration `json:"interval"`
+		MaxDuration jsonDuration `json:"max_duration,omitempty"`
+	}{plain(c), jsonDuration(c.Interval), jsonDuration(c.MaxDuration)})
+}
+
+func (c *ScraperConfig) UnmarshalJSON(data []byte) error {
+	type plain ScraperConfig
+	aux := struct {
+		*plain
+		Interval    jsonDuration `json:"interval"`
+		MaxDuration jsonDuration `json:"max_duration,omitempty"`
+	}{(*plain)(c), jsonDuration(c.Interval), jsonDuration(c.MaxDuration)}
+	if err := decodeStrict(data, &aux); err != nil {
+		return err
+	}
+	c.Interval = time.Duration(aux.Interval)
+	c.MaxDuration = time.Duration(aux.MaxDuration)
+	return nil
+}
+
+func (c AppConfig) MarshalJSON() ([]byte, error) {
+	type plain AppConfig
+	return json.Marshal(struct {
+		plain
+		MinInterval   jsonDuration `json:"min_interval,omitempty"`
+		ScrapeTimeout jsonDuration `json:"scrape_timeout,omitempty"`
+	}{plain(c), jsonDuration(c.MinInterval), jsonDuration(c.ScrapeTimeout)})
+}
+
+func (c *AppConfig) UnmarshalJSON(data []byte) error {
+	type plain AppConfig
+	aux := struct {
+		*plain
+		MinInterval   jsonDuration `json:"min_interval,omitempty"`
+		ScrapeTimeout jsonDuration `json:"scrape_timeout,omitempty"`
+	}{(*plain)(c), jsonDuration(c.MinInterval), jsonDuration(c.ScrapeTimeout)}
+	if err := decodeStrict(data, &aux); err != nil {
+		return err
+	}
+	c.MinInterval = time.Duration(aux.MinInterval)
+	c.ScrapeTimeout = time.Duration(aux.ScrapeTimeout)
+	return nil
+}
@@ -0,0 +1,212 @@
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// columns written for each post. id is left out so restored posts take
+// fresh ids from the target's sequence; history rows find their post by
+// hn_id instead. the names are also interpolated into the restore insert,
+// so they must stay plain column names.
+var backupPostColumns = []string{
+	"hn_id", "title", "url", "author", "points", "comments_count",
+	"post_time", "scraped_at", "created_at", "updated_at", "last_seen",
+	"source", "rank", "domain", "post_type",
+}
+
+// backupVersion is written in the header line; Restore refuses other
+// versions, including the SQL statement backups written before it
+const backupVersion = 2
+
+type BackupStats struct {
+	Posts   int
+	History int
+}
+
+type backupHeader struct {
+	Backup    int       `json:"scraper_backup"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// backupLine is one record of a backup file: exactly one of Post and
+// History is set, holding the row as a JSON object
+type backupLine struct {
+	Post    json.RawMessage `json:"post,omitempty"`
+	History json.RawMessage `json:"history,omitempty"`
+}
+
+// Backup streams posts, and with history their post_history rows, to w as
+// JSON lines after a header line. postgres builds each row's JSON, so the
+// rows are never held in memory. posts and history snapshots that already
+// exist are skipped on restore.
+func Backup(w io.Writer, withHistory bool) (*BackupStats, error) {
+	stats := &BackupStats{}
+	out := bufio.NewWriter(w)
+
+	header, err := json.Marshal(backupHeader{Backup: backupVersion, CreatedAt: time.Now()})
+	if err != nil {
+		return stats, err
+	}
+	out.Write(header)
+	out.WriteByte('\n')
+
+	fields := make([]string, len(backupPostColumns))
+	for i, column := range backupPostColumns {
+		fields[i] = fmt.Sprintf("'%[1]s', %[1]s", column)
+	}
+	postsQuery := fmt.Sprintf(`
+		SELECT json_build_object('post', json_build_object(%s))::text
+		FROM posts
+		ORDER BY id`, strings.Join(fields, ", "))
+
+	n, err := writeLines(out, postsQuery)
+	stats.Posts = n
+	if err != nil {
+		return stats, fmt.Errorf("failed to back up posts: %w", err)
+	}
+
+	if withHistory {
+		n, err := writeLines(out, `
+			SELECT json_build_object('history', json_build_object(
+				'hn_id', p.hn_id, 'points', h.points,
+				'comments_count', h.comments_count, 'recorded_at', h.recorded_at))::text
+			FROM post_history h
+			JOIN posts p ON p.id = h.post_id
+			ORDER BY h.id`)
+		stats.History = n
+		if err != nil {
+			return stats, fmt.Errorf("failed to back up history: %w", err)
+		}
+	}
+
+	if err := out.Flush(); err != nil {
+		return stats, fmt.Errorf("failed to write backup: %w", err)
+	}
+	return stats, nil
+}
+
+func writeLines(out *bufio.Writer, query string) (int, error) {
+	rows, err := GetQuerier().Query(query)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return count, err
+		}
+		if _, err := out.WriteString(line + "\n"); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, rows.Err()
+}
+
+// restore statements take the whole JSON record as their only parameter,
+// so nothing read from the file is ever parsed as SQL
+var (
+	restorePostQuery = fmt.Sprintf(`
+		INSERT INTO posts (%[1]s)
+		SELECT %[1]s FROM json_populate_record(NULL::posts, $1::json)
+		ON CONFLICT (hn_id) DO NOTHING`, strings.Join(backupPostColumns, ", "))
+
+	// a snapshot already stored for the post at the same time is skipped,
+	// so restoring over existing data or twice doesn't duplicate history
+	restoreHistoryQuery = `
+		INSERT INTO post_history (post_id, points, comments_count, recorded_at)
+		SELECT p.id, h.points, h.comments_count, h.recorded_at
+		FROM json_to_record($1::json) AS h(hn_id INTEGER, points INTEGER, comments_count INTEGER, recorded_at TIMESTAMP)
+		JOIN posts p ON p.hn_id = h.hn_id
+		WHERE NOT EXISTS (
+			SELECT 1 FROM post_history existing
+			WHERE existing.post_id = p.id AND existing.recorded_at = h.recorded_at
+		)`
+)
+
+// Restore loads a file written by Backup inside a single transaction, so a
+// failure leaves the database as it was. records are read one line at a
+// time and inserted with bound parameters.
+func Restore(r io.Reader) (*BackupStats, error) {
+	reader := bufio.NewReader(r)
+
+	first, err := readLine(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup: %w", err)
+	}
+	var header backupHeader
+	if json.Unmarshal(first, &header) != nil || header.Backup != backupVersion {
+		return nil, fmt.Errorf("not a scraper backup (version %d); SQL backups from older versions can't be restored", backupVersion)
+	}
+
+	tx, err := GetQuerier().Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stats := &BackupStats{}
+	for n := 2; ; n++ {
+		line, err := readLine(reader)
+		if err != nil {
+			return stats, fmt.Errorf("failed to read backup: %w", err)
+		}
+		if line == nil {
+			break
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var record backupLine
+		if err := json.Unmarshal(line, &record); err != nil {
+			return stats, fmt.Errorf("line %d is not valid JSON: %w", n, err)
+		}
+
+		var query string
+		var count *int
+		var data json.RawMessage
+		switch {
+		case record.Post != nil && record.History == nil:
+			query, count, data = restorePostQuery, &stats.Posts, record.Post
+		case record.History != nil && record.Post == nil:
+			query, count, data = restoreHistoryQuery, &stats.History, record.History
+		default:
+			return stats, fmt.Errorf("line %d is not a post or history record", n)
+		}
+
+		result, err := tx.Exec(query, string(data))
+		if err != nil {
+			return stats, fmt.Errorf("failed to restore line %d: %w", n, err)
+		}
+		affected, _ := result.RowsAffected()
+		*count += int(affected)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return stats, fmt.Errorf("failed to commit restore: %w", err)
+	}
+	return stats, nil
+}
+
+// readLine returns the next line without its newline, or nil at the end
+// of the input. lines have no length limit, unlike with bufio.Scanner.
+func readLine(reader *bufio.Reader) ([]byte, error) {
+	line, err := reader.ReadBytes('\n')
+	if err == io.EOF && len(line) == 0 {
+		return nil, nil
+	}
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return bytes.TrimRight(line, "\r\n"), nil
+}
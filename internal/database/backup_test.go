@@ -0,0 +1,43 @@
+package database
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dzmitry-papkou/scraper/internal/models"
+)
+
+func TestRestoreSkipsExistingHistory(t *testing.T) {
+	repo := newTestRepo(t)
+	t.Cleanup(func() { GetDB().Exec("DELETE FROM posts WHERE hn_id >= $1", testHnIDBase) })
+
+	post := &models.Post{HnID: testHnIDBase + 900, Title: "Backed up", Author: "tester", Points: 10}
+	if _, err := repo.UpsertPost(post, UpdateScores); err != nil {
+		t.Fatal(err)
+	}
+	for points := 10; points <= 20; points += 10 {
+		if err := repo.InsertPostHistory(post.ID, points, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var backup bytes.Buffer
+	if _, err := Backup(&backup, true); err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+
+	// the post and both snapshots are already stored, so neither restore
+	// adds anything for it
+	for run := 1; run <= 2; run++ {
+		if _, err := Restore(bytes.NewReader(backup.Bytes())); err != nil {
+			t.Fatalf("Restore %d: %v", run, err)
+		}
+		history, err := repo.GetPostHistory(post.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(history) != 2 {
+			t.Errorf("after restore %d the post has %d history rows, want 2", run, len(history))
+		}
+	}
+}
@@ -10,19 +10,25 @@ import (
 )
 
 type Config struct {
-	Host     string
-	Port     int
-	User     string
-	Password string
-	Database string
-	SSLMode  string
+	Host             string
+	Port             int
+	User             string
+	Password         string
+	Database         string
+	SSLMode          string
+	ConnectTimeout   time.Duration
+	ApplicationName  string
+	SearchPath       string
+	StatementTimeout time.Duration
 }
 
 var db *sql.DB
 
 func Initialize(cfg Config) error {
-	connStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Database, cfg.SSLMode)
+	connStr, err := cfg.DSN()
+	if err != nil {
+		return err
+	}
 
 	return initializeDB(connStr, 25, 5, 5*time.Minute)
 }
@@ -32,12 +38,18 @@ func InitializeWithURL(url string, maxOpen, maxIdle int, connLifetime time.Durat
 }
 
 func initializeDB(connStr string, maxOpen, maxIdle int, connLifetime time.Duration) error {
+	if verbose {
+		log.Printf("Connecting to %s", redactConnString(connStr))
+	}
+
 	var err error
 	db, err = sql.Open("postgres", connStr)
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
 
+	connectedHost = hostOf(connStr)
+
 	db.SetMaxOpenConns(maxOpen)
 	db.SetMaxIdleConns(maxIdle)
 	db.SetConnMaxLifetime(connLifetime)
@@ -50,6 +62,12 @@ func initializeDB(connStr string, maxOpen, maxIdle int, connLifetime time.Durati
 	return nil
 }
 
+// UseDB makes the package run on an already opened pool instead of one
+// from Initialize, e.g. a stub driver in tests
+func UseDB(conn *sql.DB) {
+	db = conn
+}
+
 func GetDB() *sql.DB {
 	return db
 }
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/lib/pq"
+)
+
+// DSN assembles a postgres URL from the structured connection fields
+func (c Config) DSN() (string, error) {
+	u := &url.URL{
+		Scheme: "postgres",
+		Host:   net.JoinHostPort(c.Host, strconv.Itoa(c.Port)),
+		Path:   "/" + c.Database,
+	}
+	if c.User != "" {
+		u.User = url.UserPassword(c.User, c.Password)
+	}
+
+	// lib/pq defaults to sslmode=require, which a local docker postgres rejects
+	if c.SSLMode == "" {
+		c.SSLMode = "disable"
+	}
+
+	return c.WithParams(u.String())
+}
+
+// WithParams sets the connection parameters configured on c onto an existing
+// postgres URL; parameters left empty keep whatever the URL already carries
+func (c Config) WithParams(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid database url: %w", err)
+	}
+	if u.Scheme != "postgres" && u.Scheme != "postgresql" {
+		return "", fmt.Errorf("invalid database url: expected postgres:// scheme, got %q", u.Scheme)
+	}
+
+	q := u.Query()
+	setParam(q, "sslmode", c.SSLMode)
+	setParam(q, "application_name", c.ApplicationName)
+	// lib/pq forwards unknown parameters to the server as run-time settings,
+	// so these apply to every pooled connection rather than just one session
+	setParam(q, "search_path", c.SearchPath)
+	if c.ConnectTimeout > 0 {
+		seconds := int(math.Ceil(c.ConnectTimeout.Seconds()))
+		q.Set("connect_timeout", strconv.Itoa(seconds))
+	}
+	if c.StatementTimeout > 0 {
+		q.Set("statement_timeout", strconv.FormatInt(c.StatementTimeout.Milliseconds(), 10))
+	}
+	u.RawQuery = q.Encode()
+
+	dsn := u.String()
+	if _, err := pq.ParseURL(dsn); err != nil {
+		return "", fmt.Errorf("invalid database url: %w", err)
+	}
+	return dsn, nil
+}
+
+func setParam(q url.Values, key, value string) {
+	if value != "" {
+		q.Set(key, value)
+	}
+}
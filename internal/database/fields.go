@@ -0,0 +1,43 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// numeric expressions that analysis queries may interpolate; user input is
+// always resolved through this whitelist before reaching fmt.Sprintf
+var numericFields = map[string]string{
+	"points":         "points",
+	"comments":       "comments_count",
+	"comments_count": "comments_count",
+	"title_length":   "LENGTH(title)",
+	"hour":           "EXTRACT(HOUR FROM post_time)",
+	"dow":            "EXTRACT(DOW FROM post_time)",
+	"rank":           "rank",
+}
+
+// ResolveNumericField maps a field alias (or its exact SQL expression)
+// to the whitelisted SQL expression
+func ResolveNumericField(name string) (string, error) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if expr, ok := numericFields[key]; ok {
+		return expr, nil
+	}
+	for _, expr := range numericFields {
+		if strings.EqualFold(expr, strings.TrimSpace(name)) {
+			return expr, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported field %q (allowed: %s)", name, strings.Join(NumericFieldNames(), ", "))
+}
+
+func NumericFieldNames() []string {
+	names := make([]string, 0, len(numericFields))
+	for name := range numericFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
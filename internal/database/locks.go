@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+)
+
+var ErrScrapeInProgress = errors.New("another scrape is in progress")
+
+// advisory locks are keyed by (namespace, hashtext(scraper)); the namespace
+// keeps them clear of locks other applications take on the same database
+const scrapeLockNamespace = 0x484e // "HN"
+
+// ScrapeLock is a postgres advisory lock held for the duration of one scrape.
+// advisory locks belong to a session, so the lock keeps its own connection
+// out of the pool; if the process dies the server drops the lock with it.
+type ScrapeLock struct {
+	conn    *sql.Conn
+	scraper string
+}
+
+// AcquireScrapeLock takes the lock for scraper without waiting, returning
+// ErrScrapeInProgress when another session already holds it
+func AcquireScrapeLock(scraper string) (*ScrapeLock, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection for scrape lock: %w", err)
+	}
+
+	var acquired bool
+	err = conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1, hashtext($2))`,
+		scrapeLockNamespace, scraper).Scan(&acquired)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to take scrape lock: %w", err)
+	}
+	if !acquired {
+		conn.Close()
+		return nil, fmt.Errorf("%w for scraper %s", ErrScrapeInProgress, scraper)
+	}
+
+	return &ScrapeLock{conn: conn, scraper: scraper}, nil
+}
+
+// Release unlocks and returns the connection to the pool
+func (l *ScrapeLock) Release() {
+	_, err := l.conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1, hashtext($2))`,
+		scrapeLockNamespace, l.scraper)
+	if err != nil {
+		log.Printf("Warning: Could not release scrape lock for %s: %v", l.scraper, err)
+	}
+	l.conn.Close()
+}
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"fmt"
+)
+
+// schema changes applied on top of env/postgres/init.sql, so databases
+// created before a column was introduced keep working. every statement
+// must be idempotent because the whole list runs on each startup.
+// the base tables come first so a database emptied by ResetSchema can be
+// rebuilt without re-running init.sql.
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS posts (
+		id SERIAL PRIMARY KEY,
+		hn_id INTEGER UNIQUE NOT NULL,
+		title TEXT NOT NULL,
+		url TEXT,
+		author VARCHAR(255) NOT NULL,
+		points INTEGER DEFAULT 0,
+		comments_count INTEGER DEFAULT 0,
+		post_time TIMESTAMP,
+		scraped_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS post_history (
+		id SERIAL PRIMARY KEY,
+		post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+		points INTEGER DEFAULT 0,
+		comments_count INTEGER DEFAULT 0,
+		recorded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS scraping_jobs (
+		id SERIAL PRIMARY KEY,
+		started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		completed_at TIMESTAMP,
+		status VARCHAR(50) DEFAULT 'running',
+		posts_scraped INTEGER DEFAULT 0,
+		error_message TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS analysis_results (
+		id SERIAL PRIMARY KEY,
+		analysis_type VARCHAR(100) NOT NULL,
+		analysis_date DATE NOT NULL,
+		results TEXT NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_posts_post_time ON posts(post_time DESC)`,
+	`CREATE INDEX IF NOT EXISTS idx_posts_author ON posts(author)`,
+	`CREATE INDEX IF NOT EXISTS idx_posts_points ON posts(points DESC)`,
+	`CREATE INDEX IF NOT EXISTS idx_posts_scraped_at ON posts(scraped_at DESC)`,
+	`CREATE INDEX IF NOT EXISTS idx_posts_updated_at ON posts(updated_at DESC)`,
+	`CREATE INDEX IF NOT EXISTS idx_post_history_post_id ON post_history(post_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_post_history_recorded_at ON post_history(recorded_at DESC)`,
+	`CREATE INDEX IF NOT EXISTS idx_scraping_jobs_started_at ON scraping_jobs(started_at DESC)`,
+	`CREATE OR REPLACE FUNCTION update_updated_at_column()
+	RETURNS TRIGGER AS $$
+	BEGIN
+		NEW.updated_at = CURRENT_TIMESTAMP;
+		RETURN NEW;
+	END;
+	$$ LANGUAGE plpgsql`,
+	ensureUpdatedAtTrigger("posts"),
+	`ALTER TABLE posts ADD COLUMN IF NOT EXISTS last_seen TIMESTAMP`,
+	`ALTER TABLE scraping_jobs ADD COLUMN IF NOT EXISTS details JSONB`,
+	`CREATE TABLE IF NOT EXISTS post_title_history (
+		id SERIAL PRIMARY KEY,
+		post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+		old_title TEXT NOT NULL,
+		new_title TEXT NOT NULL,
+		changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_post_title_history_changed_at ON post_title_history(changed_at DESC)`,
+	`ALTER TABLE posts ADD COLUMN IF NOT EXISTS source VARCHAR(100) NOT NULL DEFAULT 'hackernews'`,
+	`CREATE INDEX IF NOT EXISTS idx_posts_source ON posts(source)`,
+	`ALTER TABLE posts ADD COLUMN IF NOT EXISTS rank INTEGER`,
+	`ALTER TABLE posts ADD COLUMN IF NOT EXISTS domain VARCHAR(255)`,
+	`ALTER TABLE posts ADD COLUMN IF NOT EXISTS post_type VARCHAR(20)`,
+	`CREATE TABLE IF NOT EXISTS stats_summary (
+		id SERIAL PRIMARY KEY,
+		computed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		summary JSONB NOT NULL
+	)`,
+	// partial posts have no known post_time; checked first so a current
+	// schema isn't locked on every startup
+	dropPostTimeNotNull("posts"),
+}
+
+// ensureUpdatedAtTrigger creates table's updated_at trigger unless it
+// exists. the check keeps startup from locking the table every time, and
+// OR REPLACE lets two processes starting together both succeed.
+func ensureUpdatedAtTrigger(table string) string {
+	return fmt.Sprintf(`DO $$
+	BEGIN
+		IF NOT EXISTS (
+			SELECT 1 FROM pg_trigger
+			WHERE tgname = 'update_%[1]s_updated_at' AND tgrelid = '%[1]s'::regclass
+		) THEN
+			CREATE OR REPLACE TRIGGER update_%[1]s_updated_at
+				BEFORE UPDATE ON %[1]s
+				FOR EACH ROW
+				EXECUTE FUNCTION update_updated_at_column();
+		END IF;
+	END $$`, table)
+}
+
+// dropPostTimeNotNull makes post_time nullable on table if it isn't yet
+func dropPostTimeNotNull(table string) string {
+	return fmt.Sprintf(`DO $$
+	BEGIN
+		IF EXISTS (
+			SELECT 1 FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = '%[1]s'
+			  AND column_name = 'post_time' AND is_nullable = 'NO'
+		) THEN
+			ALTER TABLE %[1]s ALTER COLUMN post_time DROP NOT NULL;
+		END IF;
+	END $$`, table)
+}
+
+func Migrate() error {
+	q := GetQuerier()
+	for i, stmt := range migrations {
+		if _, err := q.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to apply migration #%d: %w", i+1, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// query parameters that only track where a click came from
+var trackingParams = map[string]bool{
+	"ref":    true,
+	"fbclid": true,
+	"gclid":  true,
+}
+
+// NormalizeURL reduces a link to a canonical form so resubmissions of the
+// same article compare equal: scheme, www., default ports, fragments,
+// trailing slashes and tracking parameters are dropped, and the remaining
+// query is sorted. unparseable input is returned trimmed and lowercased.
+func NormalizeURL(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return strings.ToLower(rawURL)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	host = strings.TrimPrefix(host, "www.")
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+
+	path := strings.TrimRight(u.EscapedPath(), "/")
+
+	query := u.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		if trackingParams[strings.ToLower(key)] || strings.HasPrefix(strings.ToLower(key), "utm_") {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var params []string
+	for _, key := range keys {
+		values := query[key]
+		sort.Strings(values)
+		for _, value := range values {
+			params = append(params, url.QueryEscape(key)+"="+url.QueryEscape(value))
+		}
+	}
+
+	normalized := host + path
+	if len(params) > 0 {
+		normalized += "?" + strings.Join(params, "&")
+	}
+	return normalized
+}
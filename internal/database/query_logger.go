@@ -0,0 +1,178 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Querier is the subset of *sql.DB used by the repository and analyzers
+type Querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *Row
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Begin() (*Tx, error)
+}
+
+// queryLogger wraps *sql.DB and echoes every statement when verbose mode is on
+type queryLogger struct {
+	*sql.DB
+}
+
+var verbose bool
+
+func SetVerbose(enabled bool) {
+	verbose = enabled
+}
+
+func IsVerbose() bool {
+	return verbose
+}
+
+func GetQuerier() Querier {
+	return &queryLogger{DB: db}
+}
+
+func (q *queryLogger) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	logQuery(query, args)
+	rows, err := q.DB.Query(query, args...)
+	return rows, wrapTimeout(err)
+}
+
+func (q *queryLogger) QueryRow(query string, args ...interface{}) *Row {
+	logQuery(query, args)
+	return &Row{q.DB.QueryRow(query, args...)}
+}
+
+// Row is a *sql.Row whose Scan reports statement timeouts as
+// ErrQueryTimeout, like Query and Exec do; QueryRow only sees the error
+// once the row is scanned
+type Row struct {
+	*sql.Row
+}
+
+func (r *Row) Scan(dest ...interface{}) error {
+	return wrapTimeout(r.Row.Scan(dest...))
+}
+
+func (r *Row) Err() error {
+	return wrapTimeout(r.Row.Err())
+}
+
+func (q *queryLogger) Exec(query string, args ...interface{}) (sql.Result, error) {
+	logQuery(query, args)
+	result, err := q.DB.Exec(query, args...)
+	return result, wrapTimeout(err)
+}
+
+func (q *queryLogger) Begin() (*Tx, error) {
+	logQuery("BEGIN", nil)
+	tx, err := q.DB.Begin()
+	if err != nil {
+		return nil, wrapTimeout(err)
+	}
+	return &Tx{tx: tx}, nil
+}
+
+// Tx is a transaction whose statements are logged and report timeouts
+// like the Querier's
+type Tx struct {
+	tx *sql.Tx
+}
+
+func (t *Tx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	logQuery(query, args)
+	rows, err := t.tx.Query(query, args...)
+	return rows, wrapTimeout(err)
+}
+
+func (t *Tx) QueryRow(query string, args ...interface{}) *Row {
+	logQuery(query, args)
+	return &Row{t.tx.QueryRow(query, args...)}
+}
+
+func (t *Tx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	logQuery(query, args)
+	result, err := t.tx.Exec(query, args...)
+	return result, wrapTimeout(err)
+}
+
+// Prepare returns a statement that logs its query with each execution's
+// arguments
+func (t *Tx) Prepare(query string) (*Stmt, error) {
+	stmt, err := t.tx.Prepare(query)
+	if err != nil {
+		return nil, wrapTimeout(err)
+	}
+	return &Stmt{stmt: stmt, query: query}, nil
+}
+
+func (t *Tx) Commit() error {
+	logQuery("COMMIT", nil)
+	return wrapTimeout(t.tx.Commit())
+}
+
+// Rollback is deferred after Begin, so it is only logged when it undoes
+// something
+func (t *Tx) Rollback() error {
+	err := t.tx.Rollback()
+	if err != sql.ErrTxDone {
+		logQuery("ROLLBACK", nil)
+	}
+	return err
+}
+
+type Stmt struct {
+	stmt  *sql.Stmt
+	query string
+}
+
+func (s *Stmt) Exec(args ...interface{}) (sql.Result, error) {
+	logQuery(s.query, args)
+	result, err := s.stmt.Exec(args...)
+	return result, wrapTimeout(err)
+}
+
+func (s *Stmt) Close() error {
+	return s.stmt.Close()
+}
+
+var ErrQueryTimeout = errors.New("query timed out")
+
+// postgres reports statement_timeout as query_canceled (57014)
+func wrapTimeout(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "57014" {
+		return fmt.Errorf("%w: %s", ErrQueryTimeout, pqErr.Message)
+	}
+	return err
+}
+
+func logQuery(query string, args []interface{}) {
+	if !verbose {
+		return
+	}
+
+	// collapse the multi-line query literals into a single log line
+	compact := strings.Join(strings.Fields(query), " ")
+	if len(args) > 0 {
+		log.Printf("SQL: %s | args: %v", compact, args)
+	} else {
+		log.Printf("SQL: %s", compact)
+	}
+}
+
+var passwordParam = regexp.MustCompile(`password=\S+`)
+
+func redactConnString(connStr string) string {
+	if u, err := url.Parse(connStr); err == nil && u.User != nil {
+		return u.Redacted()
+	}
+	return passwordParam.ReplaceAllString(connStr, "password=xxxxx")
+}
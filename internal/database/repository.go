@@ -4,46 +4,190 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"log"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/dzmitry-papkou/scraper/internal/models"
+	"github.com/lib/pq"
 )
 
 type Repository struct {
-	db *sql.DB
+	db Querier
+	// posts table for scraper reads and writes, see ForTable
+	table string
 }
 
 func NewRepository() *Repository {
 	return &Repository{
-		db: GetDB(),
+		db:    GetQuerier(),
+		table: DefaultPostsTable,
 	}
 }
 
 // posts operations
 
+// row timestamps (scraped_at, updated_at, last_seen, started_at) come from
+// the database clock via CURRENT_TIMESTAMP rather than time.Now(), so they
+// agree with each other and with CURRENT_DATE filters even when the app
+// host's clock drifts
+
+// posts scraped before sources were tracked all came from hacker news
+const defaultSource = "hackernews"
+
+func postSource(post *models.Post) string {
+	if post.Source == "" {
+		return defaultSource
+	}
+	return post.Source
+}
+
+// postTime is the post_time parameter, NULL when the time is unknown
+func postTime(post *models.Post) interface{} {
+	if post.PostTime.IsZero() {
+		return nil
+	}
+	return post.PostTime
+}
+
+// NullTime scans a nullable timestamp into t, leaving it zero for NULL
+func NullTime(t *time.Time) sql.Scanner {
+	return nullTime{t}
+}
+
+type nullTime struct {
+	t *time.Time
+}
+
+func (n nullTime) Scan(value interface{}) error {
+	var nt sql.NullTime
+	if err := nt.Scan(value); err != nil {
+		return err
+	}
+	*n.t = nt.Time
+	return nil
+}
+
 func (r *Repository) InsertPost(post *models.Post) error {
-	query := `
-		INSERT INTO posts (hn_id, title, url, author, points, comments_count, post_time, scraped_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		ON CONFLICT (hn_id) DO UPDATE SET
-			points = EXCLUDED.points,
-			comments_count = EXCLUDED.comments_count,
-			updated_at = CURRENT_TIMESTAMP
-		RETURNING id`
+	_, err := r.UpsertPost(post, UpdateScores)
+	return err
+}
+
+// InsertPostIfNew leaves an existing row untouched, for archival backfill
+// where the stored scores are fresher than the ones on an old page
+func (r *Repository) InsertPostIfNew(post *models.Post) (bool, error) {
+	return r.UpsertPost(post, InsertOnly)
+}
 
+// UpsertPost stores post, resolving a conflict with an existing row
+// according to strategy, and reports whether a new row was created.
+// post's id and scores are set to the stored row's; score history is left
+// to the caller. partial posts are always insert-only.
+func (r *Repository) UpsertPost(post *models.Post, strategy UpsertStrategy) (bool, error) {
+	if post.Partial {
+		strategy = InsertOnly
+	}
+	var inserted bool
+	err := retryOnce(func() error {
+		var err error
+		inserted, err = r.upsertPost(post, strategy)
+		return err
+	})
+	return inserted, err
+}
+
+func (r *Repository) upsertPost(post *models.Post, strategy UpsertStrategy) (bool, error) {
+	// the CTE reads the pre-upsert title so mod edits can be recorded;
+	// xmax is only zero on a freshly inserted row
+	// the target is aliased as posts so the conflict clause works for any table
+	query := fmt.Sprintf(`
+		WITH previous AS (SELECT title FROM %[1]s WHERE hn_id = $1)
+		INSERT INTO %[1]s AS posts (hn_id, title, url, author, points, comments_count, post_time, scraped_at, source, rank, domain, post_type)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, CURRENT_TIMESTAMP, $8, NULLIF($9, 0), NULLIF($10, ''), NULLIF($11, ''))
+		%[2]s
+		RETURNING id, scraped_at, points, comments_count, (xmax = 0), (SELECT title FROM previous)`, r.postsTable(), strategy.conflictClause())
+
+	var inserted bool
+	var previousTitle sql.NullString
 	err := r.db.QueryRow(query,
 		post.HnID, post.Title, post.URL, post.Author,
-		post.Points, post.CommentsCount, post.PostTime, time.Now(),
-	).Scan(&post.ID)
+		post.Points, post.CommentsCount, postTime(post), postSource(post), post.Rank,
+		post.Domain, post.PostType,
+	).Scan(&post.ID, &post.ScrapedAt, &post.Points, &post.CommentsCount, &inserted, &previousTitle)
 
-	return err
+	// DO NOTHING returns no row for an existing post
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := r.recordTitleChange(post.ID, previousTitle, post.Title); err != nil {
+		log.Printf("Failed to record title change for post %d: %v", post.HnID, err)
+	}
+	return inserted, nil
+}
+
+const batchSize = 500
+
+// InsertPostsBatch inserts posts with multi-row statements, leaving rows
+// that already exist untouched, and returns how many were new
+func (r *Repository) InsertPostsBatch(posts []models.Post) (int, error) {
+	inserted := 0
+	for start := 0; start < len(posts); start += batchSize {
+		end := start + batchSize
+		if end > len(posts) {
+			end = len(posts)
+		}
+
+		var n int
+		err := retryOnce(func() error {
+			var err error
+			n, err = r.insertBatch(posts[start:end])
+			return err
+		})
+		if err != nil {
+			return inserted, err
+		}
+		inserted += n
+	}
+	return inserted, nil
+}
+
+func (r *Repository) insertBatch(posts []models.Post) (int, error) {
+	const columns = 11
+	placeholders := make([]string, 0, len(posts))
+	args := make([]interface{}, 0, len(posts)*columns)
+
+	for i, post := range posts {
+		base := i * columns
+		placeholders = append(placeholders, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, CURRENT_TIMESTAMP, $%d, NULLIF($%d, 0), NULLIF($%d, ''), NULLIF($%d, ''))",
+			base+1, base+2, base+3, base+4, base+5, base+6, base+7, base+8, base+9, base+10, base+11))
+		args = append(args, post.HnID, post.Title, post.URL, post.Author,
+			post.Points, post.CommentsCount, postTime(&post), postSource(&post), post.Rank,
+			post.Domain, post.PostType)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO %s (hn_id, title, url, author, points, comments_count, post_time, scraped_at, source, rank, domain, post_type)
+		VALUES %s
+		ON CONFLICT (hn_id) DO NOTHING`, r.postsTable(), strings.Join(placeholders, ", "))
+
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
 }
 
 func (r *Repository) GetRecentPosts(limit int) ([]models.Post, error) {
 	query := `
 		SELECT id, hn_id, title, url, author, points, comments_count, post_time, scraped_at
 		FROM posts
-		ORDER BY post_time DESC
+		ORDER BY post_time DESC NULLS LAST
 		LIMIT $1`
 
 	rows, err := r.db.Query(query, limit)
@@ -56,7 +200,7 @@ func (r *Repository) GetRecentPosts(limit int) ([]models.Post, error) {
 	for rows.Next() {
 		var p models.Post
 		err := rows.Scan(&p.ID, &p.HnID, &p.Title, &p.URL, &p.Author,
-			&p.Points, &p.CommentsCount, &p.PostTime, &p.ScrapedAt)
+			&p.Points, &p.CommentsCount, NullTime(&p.PostTime), &p.ScrapedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -66,15 +210,56 @@ func (r *Repository) GetRecentPosts(limit int) ([]models.Post, error) {
 	return posts, nil
 }
 
+// GetTodaysPosts returns the posts stored since midnight, best scoring first
+func (r *Repository) GetTodaysPosts() ([]models.Post, error) {
+	query := `
+		SELECT id, hn_id, title, url, author, points, comments_count, post_time, scraped_at
+		FROM posts
+		WHERE scraped_at >= CURRENT_DATE
+		ORDER BY points DESC, scraped_at DESC`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []models.Post
+	for rows.Next() {
+		var p models.Post
+		err := rows.Scan(&p.ID, &p.HnID, &p.Title, &p.URL, &p.Author,
+			&p.Points, &p.CommentsCount, NullTime(&p.PostTime), &p.ScrapedAt)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, p)
+	}
+
+	return posts, rows.Err()
+}
+
 func (r *Repository) GetPostCount() (int, error) {
 	var count int
 	err := r.db.QueryRow("SELECT COUNT(*) FROM posts").Scan(&count)
 	return count, err
 }
 
+// MergeAuthors rewrites every post by one author name to another, for
+// fixing case/whitespace fragmentation from before normalization
+func (r *Repository) MergeAuthors(from, to string) (int64, error) {
+	result, err := r.db.Exec(`UPDATE posts SET author = $2 WHERE author = $1`, from, to)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 // post history operations
 
 func (r *Repository) InsertPostHistory(postID int, points, comments int) error {
+	if !r.sharedTable() {
+		return nil
+	}
 	query := `
 		INSERT INTO post_history (post_id, points, comments_count)
 		VALUES ($1, $2, $3)`
@@ -83,16 +268,59 @@ func (r *Repository) InsertPostHistory(postID int, points, comments int) error {
 	return err
 }
 
+// GetPostByHnID returns the stored post, or nil if there is none
+func (r *Repository) GetPostByHnID(hnID int) (*models.Post, error) {
+	var p models.Post
+	var url sql.NullString
+	err := r.db.QueryRow(`
+		SELECT id, hn_id, title, url, author, points, comments_count, post_time, scraped_at, source
+		FROM posts
+		WHERE hn_id = $1`, hnID).Scan(&p.ID, &p.HnID, &p.Title, &url, &p.Author,
+		&p.Points, &p.CommentsCount, NullTime(&p.PostTime), &p.ScrapedAt, &p.Source)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.URL = url.String
+	return &p, nil
+}
+
+// GetPostHistory returns a post's score snapshots, oldest first
+func (r *Repository) GetPostHistory(postID int) ([]models.PostHistory, error) {
+	rows, err := r.db.Query(`
+		SELECT id, post_id, points, comments_count, recorded_at
+		FROM post_history
+		WHERE post_id = $1
+		ORDER BY recorded_at`, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []models.PostHistory
+	for rows.Next() {
+		var h models.PostHistory
+		if err := rows.Scan(&h.ID, &h.PostID, &h.Points, &h.CommentsCount, &h.RecordedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, h)
+	}
+
+	return history, rows.Err()
+}
+
 // scraping job operations
 
 func (r *Repository) CreateScrapingJob() (int, error) {
 	var jobID int
 	query := `
 		INSERT INTO scraping_jobs (started_at, status)
-		VALUES ($1, 'running')
+		VALUES (CURRENT_TIMESTAMP, 'running')
 		RETURNING id`
 
-	err := r.db.QueryRow(query, time.Now()).Scan(&jobID)
+	err := r.db.QueryRow(query).Scan(&jobID)
 	return jobID, err
 }
 
@@ -112,7 +340,7 @@ func (r *Repository) GetLastScrapingJob() (*models.ScrapingJob, error) {
 	query := `
 		SELECT id, started_at, completed_at, status, posts_scraped, error_message
 		FROM scraping_jobs
-		WHERE status = 'completed'
+		WHERE status IN ('completed', 'partial')
 		ORDER BY completed_at DESC
 		LIMIT 1`
 
@@ -128,26 +356,63 @@ func (r *Repository) GetLastScrapingJob() (*models.ScrapingJob, error) {
 
 // statistics operations
 
+// SaveStatsSummary stores a precomputed summary document and returns when
+// it was recorded
+func (r *Repository) SaveStatsSummary(summary []byte) (time.Time, error) {
+	var computedAt time.Time
+	err := r.db.QueryRow(`
+		INSERT INTO stats_summary (summary)
+		VALUES ($1)
+		RETURNING computed_at`, string(summary)).Scan(&computedAt)
+	return computedAt, err
+}
+
+// GetLatestStatsSummary returns the most recent summary document, or nil
+// if summarize has never been run
+func (r *Repository) GetLatestStatsSummary() ([]byte, time.Time, error) {
+	var summary string
+	var computedAt time.Time
+	err := r.db.QueryRow(`
+		SELECT summary, computed_at
+		FROM stats_summary
+		ORDER BY computed_at DESC
+		LIMIT 1`).Scan(&summary, &computedAt)
+	if err == sql.ErrNoRows {
+		return nil, time.Time{}, nil
+	}
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return []byte(summary), computedAt, nil
+}
+
 func (r *Repository) GetBasicStats() (map[string]interface{}, error) {
+	return r.GetBasicStatsForSource("")
+}
+
+// GetBasicStatsForSource is GetBasicStats over the posts of one source, or
+// all posts when source is empty
+func (r *Repository) GetBasicStatsForSource(source string) (map[string]interface{}, error) {
 	stats := make(map[string]interface{})
+	const where = "WHERE ($1 = '' OR source = $1)"
 
 	var totalPosts int
-	r.db.QueryRow("SELECT COUNT(*) FROM posts").Scan(&totalPosts)
+	r.db.QueryRow("SELECT COUNT(*) FROM posts "+where, source).Scan(&totalPosts)
 	stats["total_posts"] = totalPosts
 
 	var uniqueAuthors int
-	r.db.QueryRow("SELECT COUNT(DISTINCT author) FROM posts").Scan(&uniqueAuthors)
+	r.db.QueryRow("SELECT COUNT(DISTINCT author) FROM posts "+where, source).Scan(&uniqueAuthors)
 	stats["unique_authors"] = uniqueAuthors
 
 	var avgPoints, avgComments sql.NullFloat64
-	r.db.QueryRow("SELECT AVG(points), AVG(comments_count) FROM posts").Scan(&avgPoints, &avgComments)
+	r.db.QueryRow("SELECT AVG(points), AVG(comments_count) FROM posts "+where, source).Scan(&avgPoints, &avgComments)
 
 	stats["avg_points"] = avgPoints.Float64
 	stats["avg_comments"] = avgComments.Float64
 
 	var maxPoints, maxComments int
-	r.db.QueryRow("SELECT COALESCE(MAX(points), 0) FROM posts").Scan(&maxPoints)
-	r.db.QueryRow("SELECT COALESCE(MAX(comments_count), 0) FROM posts").Scan(&maxComments)
+	r.db.QueryRow("SELECT COALESCE(MAX(points), 0) FROM posts "+where, source).Scan(&maxPoints)
+	r.db.QueryRow("SELECT COALESCE(MAX(comments_count), 0) FROM posts "+where, source).Scan(&maxComments)
 	stats["max_points"] = maxPoints
 	stats["max_comments"] = maxComments
 
@@ -155,13 +420,20 @@ func (r *Repository) GetBasicStats() (map[string]interface{}, error) {
 }
 
 func (r *Repository) GetTopPosts(limit int) ([]models.Post, error) {
+	return r.GetTopPostsForSource("", limit)
+}
+
+// GetTopPostsForSource is GetTopPosts over the posts of one source, or all
+// posts when source is empty
+func (r *Repository) GetTopPostsForSource(source string, limit int) ([]models.Post, error) {
 	query := `
 		SELECT id, hn_id, title, url, author, points, comments_count, post_time, scraped_at
 		FROM posts
+		WHERE ($1 = '' OR source = $1)
 		ORDER BY points DESC
-		LIMIT $1`
+		LIMIT $2`
 
-	rows, err := r.db.Query(query, limit)
+	rows, err := r.db.Query(query, source, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -171,7 +443,7 @@ func (r *Repository) GetTopPosts(limit int) ([]models.Post, error) {
 	for rows.Next() {
 		var p models.Post
 		err := rows.Scan(&p.ID, &p.HnID, &p.Title, &p.URL, &p.Author,
-			&p.Points, &p.CommentsCount, &p.PostTime, &p.ScrapedAt)
+			&p.Points, &p.CommentsCount, NullTime(&p.PostTime), &p.ScrapedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -183,21 +455,6 @@ func (r *Repository) GetTopPosts(limit int) ([]models.Post, error) {
 
 // analysis queries
 
-func (r *Repository) GetCorrelation(field1, field2 string) (float64, error) {
-	var correlation sql.NullFloat64
-	query := fmt.Sprintf(`
-		SELECT CORR(%s::numeric, %s::numeric)
-		FROM posts
-		WHERE %s > 0 AND %s > 0`,
-		field1, field2, field1, field2)
-	
-	err := r.db.QueryRow(query).Scan(&correlation)
-	if err != nil || !correlation.Valid {
-		return 0, err
-	}
-	return correlation.Float64, nil
-}
-
 func (r *Repository) GetWeekdayWeekendStats() (weekdayAvg, weekendAvg float64, weekdayCount, weekendCount int, err error) {
 	err = r.db.QueryRow(`
 		SELECT COUNT(*), COALESCE(AVG(points), 0)
@@ -218,49 +475,114 @@ func (r *Repository) GetWeekdayWeekendStats() (weekdayAvg, weekendAvg float64, w
 
 func (r *Repository) GetLatestHNPostID() (int, error) {
 	var maxID int
-	err := r.db.QueryRow(`
+	err := r.db.QueryRow(fmt.Sprintf(`
 		SELECT COALESCE(MAX(hn_id), 0) 
-		FROM posts 
-	`).Scan(&maxID)
+		FROM %s 
+	`, r.postsTable())).Scan(&maxID)
 	return maxID, err
 }
 
-func (r *Repository) PostExists(hnID int) (bool, error) {
+// SourceExists reports whether any post in posts came from source
+func (r *Repository) SourceExists(source string) (bool, error) {
 	var exists bool
 	err := r.db.QueryRow(`
-		SELECT EXISTS(SELECT 1 FROM posts WHERE hn_id = $1)
-	`, hnID).Scan(&exists)
+		SELECT EXISTS(SELECT 1 FROM posts WHERE source = $1)
+	`, source).Scan(&exists)
 	return exists, err
 }
 
+func (r *Repository) PostExists(hnID int) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(fmt.Sprintf(`
+		SELECT EXISTS(SELECT 1 FROM %s WHERE hn_id = $1)
+	`, r.postsTable()), hnID).Scan(&exists)
+	return exists, err
+}
+
+// FilterExistingIDs checks a whole page of ids in one round-trip and
+// returns the subset already stored
+func (r *Repository) FilterExistingIDs(hnIDs []int) (map[int]bool, error) {
+	existing := make(map[int]bool, len(hnIDs))
+	if len(hnIDs) == 0 {
+		return existing, nil
+	}
+
+	ids := make([]int64, len(hnIDs))
+	for i, id := range hnIDs {
+		ids[i] = int64(id)
+	}
+
+	rows, err := r.db.Query(fmt.Sprintf(`SELECT hn_id FROM %s WHERE hn_id = ANY($1)`, r.postsTable()), pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hnID int
+		if err := rows.Scan(&hnID); err != nil {
+			return nil, err
+		}
+		existing[hnID] = true
+	}
+
+	return existing, rows.Err()
+}
+
 func (r *Repository) UpdatePost(post *models.Post) error {
-	query := `
-		UPDATE posts 
-		SET points = $1, 
-		    comments_count = $2,
+	return r.UpdatePostWithStrategy(post, UpdateScores)
+}
+
+// UpdatePostWithStrategy refreshes an existing post's scores as strategy
+// allows and sets post's id and scores to the stored row's, like UpsertPost;
+// InsertOnly and partial posts make it a no-op
+func (r *Repository) UpdatePostWithStrategy(post *models.Post, strategy UpsertStrategy) error {
+	if strategy == InsertOnly || post.Partial {
+		return nil
+	}
+	return retryOnce(func() error { return r.updatePost(post, strategy) })
+}
+
+func (r *Repository) updatePost(post *models.Post, strategy UpsertStrategy) error {
+	query := fmt.Sprintf(`
+		WITH previous AS (SELECT title FROM %[1]s WHERE hn_id = $4)
+		UPDATE %[1]s AS posts 
+		SET %[2]s,
+		    title = COALESCE(NULLIF($3, ''), posts.title),
 		    updated_at = CURRENT_TIMESTAMP,
 		    last_seen = CURRENT_TIMESTAMP
-		WHERE hn_id = $3`
+		WHERE hn_id = $4
+		RETURNING id, points, comments_count, (SELECT title FROM previous)`, r.postsTable(), strategy.scoreAssignments("$1", "$2"))
 	
-	_, err := r.db.Exec(query, post.Points, post.CommentsCount, post.HnID)
+	var previousTitle sql.NullString
+	err := r.db.QueryRow(query, post.Points, post.CommentsCount, post.Title, post.HnID).
+		Scan(&post.ID, &post.Points, &post.CommentsCount, &previousTitle)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
 	
-	if err == nil {
-		r.recordPostHistory(post.HnID, post.Points, post.CommentsCount)
+	if err := r.recordTitleChange(post.ID, previousTitle, post.Title); err != nil {
+		log.Printf("Failed to record title change for post %d: %v", post.HnID, err)
 	}
 	
-	return err
+	return nil
 }
 
-func (r *Repository) GetRecentPostsNotUpdatedSince(since time.Time, limit int) ([]models.Post, error) {
-	query := `
+// GetRecentPostsNotUpdatedFor returns posts from the last week whose row
+// hasn't been touched for at least age, measured on the database clock
+func (r *Repository) GetRecentPostsNotUpdatedFor(age time.Duration, limit int) ([]models.Post, error) {
+	query := fmt.Sprintf(`
 		SELECT id, hn_id, title, url, author, points, comments_count, post_time, scraped_at
-		FROM posts
-		WHERE updated_at < $1 
+		FROM %s
+		WHERE updated_at < CURRENT_TIMESTAMP - $1 * INTERVAL '1 second' 
 		  AND post_time > CURRENT_TIMESTAMP - INTERVAL '7 days'
 		ORDER BY post_time DESC
-		LIMIT $2`
+		LIMIT $2`, r.postsTable())
 	
-	rows, err := r.db.Query(query, since, limit)
+	rows, err := r.db.Query(query, age.Seconds(), limit)
 	if err != nil {
 		return nil, err
 	}
@@ -270,7 +592,7 @@ func (r *Repository) GetRecentPostsNotUpdatedSince(since time.Time, limit int) (
 	for rows.Next() {
 		var p models.Post
 		err := rows.Scan(&p.ID, &p.HnID, &p.Title, &p.URL, &p.Author,
-			&p.Points, &p.CommentsCount, &p.PostTime, &p.ScrapedAt)
+			&p.Points, &p.CommentsCount, NullTime(&p.PostTime), &p.ScrapedAt)
 		if err != nil {
 			continue
 		}
@@ -281,11 +603,11 @@ func (r *Repository) GetRecentPostsNotUpdatedSince(since time.Time, limit int) (
 }
 
 func (r *Repository) GetPostsSinceID(hnID int) ([]models.Post, error) {
-	query := `
+	query := fmt.Sprintf(`
 		SELECT id, hn_id, title, url, author, points, comments_count, post_time, scraped_at
-		FROM posts
+		FROM %s
 		WHERE hn_id > $1
-		ORDER BY hn_id DESC`
+		ORDER BY hn_id DESC`, r.postsTable())
 	
 	rows, err := r.db.Query(query, hnID)
 	if err != nil {
@@ -297,7 +619,7 @@ func (r *Repository) GetPostsSinceID(hnID int) ([]models.Post, error) {
 	for rows.Next() {
 		var p models.Post
 		err := rows.Scan(&p.ID, &p.HnID, &p.Title, &p.URL, &p.Author,
-			&p.Points, &p.CommentsCount, &p.PostTime, &p.ScrapedAt)
+			&p.Points, &p.CommentsCount, NullTime(&p.PostTime), &p.ScrapedAt)
 		if err != nil {
 			continue
 		}
@@ -307,48 +629,548 @@ func (r *Repository) GetPostsSinceID(hnID int) ([]models.Post, error) {
 	return posts, nil
 }
 
-func (r *Repository) recordPostHistory(hnID, points, comments int) error {
-	var postID int
-	err := r.db.QueryRow("SELECT id FROM posts WHERE hn_id = $1", hnID).Scan(&postID)
-	if err != nil {
-		return err
+func (r *Repository) recordTitleChange(postID int, previousTitle sql.NullString, newTitle string) error {
+	if !r.sharedTable() || !previousTitle.Valid || newTitle == "" || previousTitle.String == newTitle {
+		return nil
 	}
-	
+
 	query := `
-		INSERT INTO post_history (post_id, points, comments_count)
+		INSERT INTO post_title_history (post_id, old_title, new_title)
 		VALUES ($1, $2, $3)`
-	
-	_, err = r.db.Exec(query, postID, points, comments)
+
+	_, err := r.db.Exec(query, postID, previousTitle.String, newTitle)
 	return err
 }
 
-func (r *Repository) CreateDetailedScrapingJob(result interface{}) error {
-	resultJSON, err := json.Marshal(result)
+func (r *Repository) GetTitleChanges(limit int) ([]models.TitleChange, error) {
+	query := `
+		SELECT th.id, th.post_id, p.hn_id, th.old_title, th.new_title, th.changed_at
+		FROM post_title_history th
+		JOIN posts p ON p.id = th.post_id
+		ORDER BY th.changed_at DESC
+		LIMIT $1`
+
+	rows, err := r.db.Query(query, limit)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	
+	defer rows.Close()
+
+	var changes []models.TitleChange
+	for rows.Next() {
+		var tc models.TitleChange
+		err := rows.Scan(&tc.ID, &tc.PostID, &tc.HnID, &tc.OldTitle, &tc.NewTitle, &tc.ChangedAt)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, tc)
+	}
+
+	return changes, nil
+}
+
+// GetMostTrackedPosts returns the posts with the most history snapshots,
+// breaking ties by the longest span between the first and last snapshot
+func (r *Repository) GetMostTrackedPosts(limit int) ([]models.TrackedPost, error) {
+	query := `
+		SELECT p.id, p.hn_id, p.title, p.author, p.points, p.comments_count, p.post_time,
+		       h.snapshots, h.first_seen, h.last_seen
+		FROM (
+			SELECT post_id, COUNT(*) as snapshots,
+			       MIN(recorded_at) as first_seen, MAX(recorded_at) as last_seen
+			FROM post_history
+			GROUP BY post_id
+		) h
+		JOIN posts p ON p.id = h.post_id
+		ORDER BY h.snapshots DESC, h.last_seen - h.first_seen DESC
+		LIMIT $1`
+
+	rows, err := r.db.Query(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tracked []models.TrackedPost
+	for rows.Next() {
+		var tp models.TrackedPost
+		p := &tp.Post
+		err := rows.Scan(&p.ID, &p.HnID, &p.Title, &p.Author, &p.Points, &p.CommentsCount, NullTime(&p.PostTime),
+			&tp.Snapshots, &tp.FirstSeen, &tp.LastSeen)
+		if err != nil {
+			return nil, err
+		}
+		tracked = append(tracked, tp)
+	}
+
+	return tracked, rows.Err()
+}
+
+// GetFastestToThreshold returns the posts whose history reached points
+// soonest after submission, fastest first. posts already above the
+// threshold in their first snapshot count from that snapshot.
+func (r *Repository) GetFastestToThreshold(points, limit int) ([]models.ThresholdPost, error) {
+	query := `
+		SELECT p.id, p.hn_id, p.title, p.author, p.points, p.comments_count, p.post_time,
+		       h.reached_at, EXTRACT(EPOCH FROM h.reached_at - p.post_time)
+		FROM (
+			SELECT post_id, MIN(recorded_at) as reached_at
+			FROM post_history
+			WHERE points >= $1
+			GROUP BY post_id
+		) h
+		JOIN posts p ON p.id = h.post_id
+		WHERE h.reached_at >= p.post_time
+		ORDER BY h.reached_at - p.post_time
+		LIMIT $2`
+
+	rows, err := r.db.Query(query, points, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fastest []models.ThresholdPost
+	for rows.Next() {
+		var tp models.ThresholdPost
+		var seconds float64
+		p := &tp.Post
+		err := rows.Scan(&p.ID, &p.HnID, &p.Title, &p.Author, &p.Points, &p.CommentsCount, NullTime(&p.PostTime),
+			&tp.ReachedAt, &seconds)
+		if err != nil {
+			return nil, err
+		}
+		tp.Elapsed = time.Duration(seconds * float64(time.Second))
+		fastest = append(fastest, tp)
+	}
+
+	return fastest, rows.Err()
+}
+
+// GetPostsWithoutHistory returns posts that have no post_history rows at
+// all, newest first, so they can be refreshed to start tracking them
+func (r *Repository) GetPostsWithoutHistory(limit int) ([]models.Post, error) {
+	query := `
+		SELECT p.id, p.hn_id, p.title, p.author, p.points, p.comments_count, p.post_time, p.scraped_at
+		FROM posts p
+		WHERE NOT EXISTS (SELECT 1 FROM post_history h WHERE h.post_id = p.id)
+		ORDER BY p.post_time DESC NULLS LAST
+		LIMIT $1`
+
+	rows, err := r.db.Query(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []models.Post
+	for rows.Next() {
+		var p models.Post
+		err := rows.Scan(&p.ID, &p.HnID, &p.Title, &p.Author, &p.Points, &p.CommentsCount, NullTime(&p.PostTime), &p.ScrapedAt)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, p)
+	}
+
+	return posts, rows.Err()
+}
+
+// generate_series materialises every id in the range, so keep it bounded
+const MaxGapRange = 1000000
+
+// GetMissingIDRanges returns the spans of hn_ids between start and end
+// (inclusive) that have no stored post, collapsed into consecutive ranges
+func (r *Repository) GetMissingIDRanges(start, end int) ([]models.IDRange, error) {
+	if start > end {
+		return nil, fmt.Errorf("start id %d is after end id %d", start, end)
+	}
+	if end-start+1 > MaxGapRange {
+		return nil, fmt.Errorf("range of %d ids exceeds the limit of %d", end-start+1, MaxGapRange)
+	}
+
+	// consecutive missing ids share the same id - row_number() value
+	query := `
+		SELECT MIN(id), MAX(id)
+		FROM (
+			SELECT g.id, g.id - ROW_NUMBER() OVER (ORDER BY g.id) as grp
+			FROM generate_series($1::int, $2::int) AS g(id)
+			LEFT JOIN posts p ON p.hn_id = g.id
+			WHERE p.hn_id IS NULL
+		) missing
+		GROUP BY grp
+		ORDER BY MIN(id)`
+
+	rows, err := r.db.Query(query, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ranges []models.IDRange
+	for rows.Next() {
+		var ir models.IDRange
+		if err := rows.Scan(&ir.Start, &ir.End); err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, ir)
+	}
+
+	return ranges, rows.Err()
+}
+
+// GetCollectionRate counts posts by the day we stored them (scraped_at,
+// not post_time) over the last days days, oldest first. days with nothing
+// collected are included with a zero count so outages show up.
+func (r *Repository) GetCollectionRate(days int) ([]models.DailyCount, error) {
+	if days <= 0 {
+		return nil, fmt.Errorf("days must be positive, got %d", days)
+	}
+
+	query := `
+		SELECT d::date::text, COUNT(p.id)
+		FROM generate_series(CURRENT_DATE - ($1::int - 1), CURRENT_DATE, INTERVAL '1 day') AS d
+		LEFT JOIN posts p ON DATE(p.scraped_at) = d::date
+		GROUP BY d
+		ORDER BY d`
+
+	rows, err := r.db.Query(query, days)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []models.DailyCount
+	for rows.Next() {
+		var dc models.DailyCount
+		if err := rows.Scan(&dc.Date, &dc.Posts); err != nil {
+			return nil, err
+		}
+		counts = append(counts, dc)
+	}
+
+	return counts, rows.Err()
+}
+
+// GetDailyPostCounts counts posts by the day they were submitted
+// (post_time) for every day of year, january 1st first, zeros included
+func (r *Repository) GetDailyPostCounts(year int) ([]models.DailyCount, error) {
+	if year < 1 {
+		return nil, fmt.Errorf("invalid year %d", year)
+	}
+
+	query := `
+		SELECT d::date::text, COUNT(p.id)
+		FROM generate_series(make_date($1, 1, 1), make_date($1, 12, 31), INTERVAL '1 day') AS d
+		LEFT JOIN posts p ON p.post_time >= d AND p.post_time < d + INTERVAL '1 day'
+		GROUP BY d
+		ORDER BY d`
+
+	rows, err := r.db.Query(query, year)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []models.DailyCount
+	for rows.Next() {
+		var dc models.DailyCount
+		if err := rows.Scan(&dc.Date, &dc.Posts); err != nil {
+			return nil, err
+		}
+		counts = append(counts, dc)
+	}
+
+	return counts, rows.Err()
+}
+
+// GetSuccessRate counts finished scraping jobs per day by status over the
+// last days days, oldest first. running jobs are left out; days without
+// jobs are included with zero counts.
+func (r *Repository) GetSuccessRate(days int) ([]models.DailyJobStats, error) {
+	if days <= 0 {
+		return nil, fmt.Errorf("days must be positive, got %d", days)
+	}
+
+	query := `
+		SELECT d::date::text,
+		       COUNT(j.id) FILTER (WHERE j.status = $2),
+		       COUNT(j.id) FILTER (WHERE j.status = $3),
+		       COUNT(j.id) FILTER (WHERE j.status = $4)
+		FROM generate_series(CURRENT_DATE - ($1::int - 1), CURRENT_DATE, INTERVAL '1 day') AS d
+		LEFT JOIN scraping_jobs j ON DATE(j.started_at) = d::date
+		GROUP BY d
+		ORDER BY d`
+
+	rows, err := r.db.Query(query, days,
+		models.JobStatusCompleted, models.JobStatusPartial, models.JobStatusFailed)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []models.DailyJobStats
+	for rows.Next() {
+		var ds models.DailyJobStats
+		if err := rows.Scan(&ds.Date, &ds.Completed, &ds.Partial, &ds.Failed); err != nil {
+			return nil, err
+		}
+		stats = append(stats, ds)
+	}
+
+	return stats, rows.Err()
+}
+
+// likeEscaper makes a search term match literally inside an ILIKE pattern
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchPosts returns posts whose title contains every term, ignoring
+// case, best scored first
+func (r *Repository) SearchPosts(terms []string) ([]models.Post, error) {
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("no search terms given")
+	}
+
+	patterns := make([]string, len(terms))
+	for i, term := range terms {
+		patterns[i] = "%" + likeEscaper.Replace(term) + "%"
+	}
+
+	query := `
+		SELECT id, hn_id, title, COALESCE(url, ''), author, points, comments_count, post_time, scraped_at
+		FROM posts
+		WHERE title ILIKE ALL($1)
+		ORDER BY points DESC, post_time DESC NULLS LAST`
+
+	rows, err := r.db.Query(query, pq.Array(patterns))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []models.Post
+	for rows.Next() {
+		var p models.Post
+		err := rows.Scan(&p.ID, &p.HnID, &p.Title, &p.URL, &p.Author,
+			&p.Points, &p.CommentsCount, NullTime(&p.PostTime), &p.ScrapedAt)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, p)
+	}
+
+	return posts, rows.Err()
+}
+
+// GetDurationStats reports the spread of scrape durations for finished jobs,
+// one row per mode followed by the total, which has zero jobs when nothing
+// has finished yet. jobs started by a plain scrape
+// carry no details and are reported as mode "single".
+func (r *Repository) GetDurationStats() ([]models.DurationStats, error) {
+	query := `
+		WITH durations AS (
+			SELECT COALESCE(details->>'mode', 'single') as mode,
+			       EXTRACT(EPOCH FROM completed_at - started_at) as seconds
+			FROM scraping_jobs
+			WHERE completed_at IS NOT NULL AND status <> $1
+		)
+		SELECT CASE WHEN GROUPING(mode) = 1 THEN '' ELSE mode END,
+		       COUNT(*), COALESCE(MIN(seconds), 0), COALESCE(AVG(seconds), 0),
+		       COALESCE(PERCENTILE_CONT(0.95) WITHIN GROUP (ORDER BY seconds), 0),
+		       COALESCE(MAX(seconds), 0)
+		FROM durations
+		GROUP BY GROUPING SETS ((mode), ())
+		ORDER BY GROUPING(mode), COUNT(*) DESC`
+
+	rows, err := r.db.Query(query, models.JobStatusRunning)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seconds := func(s float64) time.Duration {
+		return time.Duration(s * float64(time.Second))
+	}
+
+	var stats []models.DurationStats
+	for rows.Next() {
+		var ds models.DurationStats
+		var minSec, avgSec, p95Sec, maxSec float64
+		if err := rows.Scan(&ds.Mode, &ds.Jobs, &minSec, &avgSec, &p95Sec, &maxSec); err != nil {
+			return nil, err
+		}
+		ds.Min, ds.Avg, ds.P95, ds.Max = seconds(minSec), seconds(avgSec), seconds(p95Sec), seconds(maxSec)
+		stats = append(stats, ds)
+	}
+
+	return stats, rows.Err()
+}
+
+const (
+	// how many standard deviations from the baseline make a run anomalous
+	anomalyStdDevs = 2.0
+	// runs of a mode needed before its baseline is trusted
+	anomalyMinBaseline = 5
+)
+
+// GetJobAnomalies compares every finished job with the window jobs of the
+// same mode that finished before it and returns those whose post count or
+// duration is more than two standard deviations from that baseline, newest
+// first. when every baseline run stored the same number of posts, any
+// other count is flagged.
+func (r *Repository) GetJobAnomalies(window int) ([]models.JobAnomaly, error) {
+	if window < anomalyMinBaseline {
+		return nil, fmt.Errorf("window must be at least %d runs, got %d", anomalyMinBaseline, window)
+	}
+
+	query := `
+		WITH runs AS (
+			SELECT id, started_at, status, posts_scraped,
+			       COALESCE(details->>'mode', 'single') as mode,
+			       EXTRACT(EPOCH FROM completed_at - started_at) as seconds
+			FROM scraping_jobs
+			WHERE completed_at IS NOT NULL AND status <> $1
+		), baselines AS (
+			SELECT id, started_at, status, mode, posts_scraped, seconds,
+			       COUNT(*) OVER w as baseline_runs,
+			       AVG(posts_scraped) OVER w as posts_mean,
+			       STDDEV_SAMP(posts_scraped) OVER w as posts_stddev,
+			       AVG(seconds) OVER w as seconds_mean,
+			       STDDEV_SAMP(seconds) OVER w as seconds_stddev
+			FROM runs
+			WINDOW w AS (PARTITION BY mode ORDER BY started_at
+			             ROWS BETWEEN $2 PRECEDING AND 1 PRECEDING)
+		)
+		SELECT id, started_at, status, mode, posts_scraped, seconds,
+		       posts_mean, posts_stddev, seconds_mean, seconds_stddev
+		FROM baselines
+		WHERE baseline_runs >= $3
+		ORDER BY started_at DESC`
+
+	rows, err := r.db.Query(query, models.JobStatusRunning, window, anomalyMinBaseline)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seconds := func(s float64) time.Duration {
+		return time.Duration(s * float64(time.Second))
+	}
+	off := func(value, mean, stddev float64) bool {
+		diff := value - mean
+		if diff < 0 {
+			diff = -diff
+		}
+		if stddev == 0 {
+			return diff > 0
+		}
+		return diff > anomalyStdDevs*stddev
+	}
+
+	var anomalies []models.JobAnomaly
+	for rows.Next() {
+		var a models.JobAnomaly
+		var sec, secMean, secStdDev float64
+		err := rows.Scan(&a.JobID, &a.StartedAt, &a.Status, &a.Mode, &a.Posts, &sec,
+			&a.PostsMean, &a.PostsStdDev, &secMean, &secStdDev)
+		if err != nil {
+			return nil, err
+		}
+		a.PostsOff = off(float64(a.Posts), a.PostsMean, a.PostsStdDev)
+		// durations jitter by a second or so, which a flat baseline would flag
+		a.DurationOff = off(sec, secMean, secStdDev) && secStdDev > 0
+		if !a.PostsOff && !a.DurationOff {
+			continue
+		}
+		a.Duration, a.DurationMean, a.DurationStdDev = seconds(sec), seconds(secMean), seconds(secStdDev)
+		anomalies = append(anomalies, a)
+	}
+
+	return anomalies, rows.Err()
+}
+
+// GetReposts groups posts whose links normalize to the same URL and
+// returns the groups with more than one submission, largest first.
+// normalization happens in Go, so every linked post is read once.
+func (r *Repository) GetReposts() ([]models.RepostGroup, error) {
+	// self posts link back to their own item page and can't be reposts
+	query := `
+		SELECT id, hn_id, title, url, author, points, comments_count, post_time, scraped_at
+		FROM posts
+		WHERE url IS NOT NULL AND url <> ''
+		  AND url NOT LIKE 'https://news.ycombinator.com/item?id=%'`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byURL := make(map[string][]models.Post)
+	for rows.Next() {
+		var p models.Post
+		err := rows.Scan(&p.ID, &p.HnID, &p.Title, &p.URL, &p.Author,
+			&p.Points, &p.CommentsCount, NullTime(&p.PostTime), &p.ScrapedAt)
+		if err != nil {
+			return nil, err
+		}
+		key := NormalizeURL(p.URL)
+		byURL[key] = append(byURL[key], p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var groups []models.RepostGroup
+	for key, posts := range byURL {
+		if len(posts) < 2 {
+			continue
+		}
+		sort.Slice(posts, func(i, j int) bool { return posts[i].Points > posts[j].Points })
+		groups = append(groups, models.RepostGroup{NormalizedURL: key, Posts: posts})
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if len(groups[i].Posts) != len(groups[j].Posts) {
+			return len(groups[i].Posts) > len(groups[j].Posts)
+		}
+		return groups[i].NormalizedURL < groups[j].NormalizedURL
+	})
+	return groups, nil
+}
+
+func (r *Repository) CreateDetailedScrapingJob(job *models.ScrapingJob) error {
+	var details sql.NullString
+	if job.Details != nil {
+		detailsJSON, err := json.Marshal(job.Details)
+		if err != nil {
+			return fmt.Errorf("failed to marshal job details: %w", err)
+		}
+		details = sql.NullString{String: string(detailsJSON), Valid: true}
+	}
+
 	query := `
 		INSERT INTO scraping_jobs (
-			started_at, 
-			completed_at, 
-			status, 
-			posts_scraped, 
+			started_at,
+			completed_at,
+			status,
+			posts_scraped,
+			error_message,
 			details
-		) VALUES ($1, $2, $3, $4, $5)`
-	
-	// extract basic fields from result need proper type assertion based on ScrapingResult
-	_, err = r.db.Exec(query, 
-		time.Now(), 
-		time.Now(), 
-		"completed", 
-		0,
-		string(resultJSON))
-	
-	return err
+		) VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`
+
+	return r.db.QueryRow(query,
+		job.StartedAt,
+		job.CompletedAt,
+		job.Status,
+		job.PostsScraped,
+		job.ErrorMessage,
+		details,
+	).Scan(&job.ID)
 }
 
-func (r *Repository) GetScrapingHistory(limit int) ([]map[string]interface{}, error) {
+func (r *Repository) GetScrapingHistory(limit int) ([]models.ScrapingJob, error) {
 	query := `
 		SELECT 
 			id,
@@ -356,6 +1178,7 @@ func (r *Repository) GetScrapingHistory(limit int) ([]map[string]interface{}, er
 			completed_at,
 			status,
 			posts_scraped,
+			error_message,
 			details
 		FROM scraping_jobs
 		ORDER BY started_at DESC
@@ -367,31 +1190,21 @@ func (r *Repository) GetScrapingHistory(limit int) ([]map[string]interface{}, er
 	}
 	defer rows.Close()
 	
-	var history []map[string]interface{}
+	var history []models.ScrapingJob
 	for rows.Next() {
-		var id int
-		var startedAt, completedAt sql.NullTime
-		var status string
-		var postsScraped int
+		var job models.ScrapingJob
 		var details sql.NullString
 		
-		err := rows.Scan(&id, &startedAt, &completedAt, &status, &postsScraped, &details)
+		err := rows.Scan(&job.ID, &job.StartedAt, &job.CompletedAt, &job.Status,
+			&job.PostsScraped, &job.ErrorMessage, &details)
 		if err != nil {
-			continue
-		}
-		
-		job := map[string]interface{}{
-			"id":            id,
-			"started_at":    startedAt.Time,
-			"completed_at":  completedAt.Time,
-			"status":        status,
-			"posts_scraped": postsScraped,
+			return nil, err
 		}
 		
 		if details.Valid {
-			var detailsMap map[string]interface{}
-			if err := json.Unmarshal([]byte(details.String), &detailsMap); err == nil {
-				job["details"] = detailsMap
+			var jobDetails models.ScrapingJobDetails
+			if err := json.Unmarshal([]byte(details.String), &jobDetails); err == nil {
+				job.Details = &jobDetails
 			}
 		}
 		
@@ -399,4 +1212,69 @@ func (r *Repository) GetScrapingHistory(limit int) ([]map[string]interface{}, er
 	}
 	
 	return history, nil
-}
\ No newline at end of file
+}
+
+// GetPostsAfterID returns up to limit posts with an id above afterID in id
+// order, for walking the whole table in batches. only the columns derived
+// fields are computed from are loaded.
+func (r *Repository) GetPostsAfterID(afterID, limit int) ([]models.Post, error) {
+	query := fmt.Sprintf(`
+		SELECT id, hn_id, title, url, COALESCE(domain, ''), COALESCE(post_type, '')
+		FROM %s
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2`, r.postsTable())
+
+	rows, err := r.db.Query(query, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []models.Post
+	for rows.Next() {
+		var p models.Post
+		if err := rows.Scan(&p.ID, &p.HnID, &p.Title, &p.URL, &p.Domain, &p.PostType); err != nil {
+			return nil, err
+		}
+		posts = append(posts, p)
+	}
+
+	return posts, rows.Err()
+}
+
+// UpdateClassifications stores the domain and post_type of posts in one
+// transaction and returns how many rows actually changed
+func (r *Repository) UpdateClassifications(posts []models.Post) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(fmt.Sprintf(`
+		UPDATE %s
+		SET domain = NULLIF($2, ''), post_type = NULLIF($3, '')
+		WHERE id = $1
+		  AND (domain IS DISTINCT FROM NULLIF($2, '') OR post_type IS DISTINCT FROM NULLIF($3, ''))`,
+		r.postsTable()))
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare update: %w", err)
+	}
+	defer stmt.Close()
+
+	updated := 0
+	for _, post := range posts {
+		result, err := stmt.Exec(post.ID, post.Domain, post.PostType)
+		if err != nil {
+			return 0, fmt.Errorf("failed to update post %d: %w", post.HnID, err)
+		}
+		affected, _ := result.RowsAffected()
+		updated += int(affected)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit: %w", err)
+	}
+	return updated, nil
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// dropped child-first; CASCADE also takes the views defined in init.sql
+var resetTables = []string{
+	"post_title_history",
+	"post_history",
+	"scraping_jobs",
+	"analysis_results",
+	"stats_summary",
+	"posts",
+}
+
+var connectedHost string
+
+// ResetSchema drops every table the scraper owns, including the dedicated
+// posts tables given, and re-runs the migrations and EnsurePostsTable,
+// leaving an empty database. meant for local development only.
+func ResetSchema(postsTables []string) error {
+	tables, err := ResetTables(postsTables)
+	if err != nil {
+		return err
+	}
+
+	q := GetQuerier()
+	stmt := fmt.Sprintf("DROP TABLE IF EXISTS %s CASCADE", strings.Join(tables, ", "))
+	if _, err := q.Exec(stmt); err != nil {
+		return fmt.Errorf("failed to drop tables: %w", err)
+	}
+	if err := Migrate(); err != nil {
+		return err
+	}
+	for _, table := range postsTables {
+		if err := EnsurePostsTable(table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResetTables lists what ResetSchema drops: the dedicated posts tables,
+// which nothing references, then the shared tables child-first
+func ResetTables(postsTables []string) ([]string, error) {
+	var tables []string
+	seen := make(map[string]bool)
+	for _, table := range postsTables {
+		if table == "" || table == DefaultPostsTable || seen[table] {
+			continue
+		}
+		if err := ValidatePostsTable(table); err != nil {
+			return nil, err
+		}
+		seen[table] = true
+		tables = append(tables, table)
+	}
+	return append(tables, resetTables...), nil
+}
+
+// IsLocal reports whether the current connection points at this machine
+func IsLocal() bool {
+	switch connectedHost {
+	case "", "localhost", "127.0.0.1", "::1":
+		return true
+	}
+	return strings.HasPrefix(connectedHost, "/")
+}
+
+func ConnectedHost() string {
+	return connectedHost
+}
+
+func hostOf(connStr string) string {
+	u, err := url.Parse(connStr)
+	if err != nil {
+		return ""
+	}
+	if host, _, err := net.SplitHostPort(u.Host); err == nil {
+		return host
+	}
+	return u.Host
+}
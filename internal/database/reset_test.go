@@ -0,0 +1,24 @@
+package database
+
+import "testing"
+
+func TestResetTablesIncludesDedicatedTables(t *testing.T) {
+	tables, err := ResetTables([]string{"", "posts", "ask_posts", "ask_posts"})
+	if err != nil {
+		t.Fatalf("ResetTables() error = %v", err)
+	}
+
+	want := append([]string{"ask_posts"}, resetTables...)
+	if len(tables) != len(want) {
+		t.Fatalf("ResetTables() = %v, want %v", tables, want)
+	}
+	for i := range want {
+		if tables[i] != want[i] {
+			t.Errorf("ResetTables()[%d] = %q, want %q", i, tables[i], want[i])
+		}
+	}
+
+	if _, err := ResetTables([]string{"posts; DROP TABLE x"}); err == nil {
+		t.Error("ResetTables() accepted an invalid table name")
+	}
+}
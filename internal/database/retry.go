@@ -0,0 +1,40 @@
+package database
+
+import (
+	"errors"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// SQLSTATE codes worth retrying: serialization_failure and deadlock_detected
+const (
+	codeSerializationFailure = "40001"
+	codeDeadlockDetected     = "40P01"
+)
+
+func isRetryable(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == codeSerializationFailure || pqErr.Code == codeDeadlockDetected
+	}
+	return false
+}
+
+// retryOnce runs write and, if postgres aborted it because of a deadlock or
+// serialization conflict with a concurrent scraper, runs it once more after
+// a short randomized delay. any other error is returned as is.
+func retryOnce(write func() error) error {
+	err := write()
+	if !isRetryable(err) {
+		return err
+	}
+
+	delay := 50*time.Millisecond + time.Duration(rand.Int63n(int64(200*time.Millisecond)))
+	log.Printf("Write conflict (%v), retrying in %s", err, delay.Round(time.Millisecond))
+	time.Sleep(delay)
+
+	return write()
+}
@@ -0,0 +1,146 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// DefaultPostsTable is where every scraper writes unless its config routes
+// it to a dedicated table
+const DefaultPostsTable = "posts"
+
+// table names are interpolated into SQL, so only plain lowercase identifiers
+// are accepted. the length leaves room for the derived sequence and trigger
+// names within postgres' 63 byte limit.
+var tableNamePattern = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,39}$`)
+
+// tables the schema uses for something other than posts
+var reservedTables = map[string]bool{
+	"post_history":       true,
+	"post_title_history": true,
+	"scraping_jobs":      true,
+	"analysis_results":   true,
+	"stats_summary":      true,
+}
+
+// ValidatePostsTable checks that name can safely be used as a posts table
+func ValidatePostsTable(name string) error {
+	if !tableNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid table name %q (lowercase letters, digits and _, at most 40 characters)", name)
+	}
+	if reservedTables[name] {
+		return fmt.Errorf("table name %q is reserved", name)
+	}
+	return nil
+}
+
+// ForTable returns a repository whose post reads and writes go to table
+// instead of the shared posts table. score and title history reference
+// posts(id), so they are only recorded for the shared table.
+func (r *Repository) ForTable(table string) (*Repository, error) {
+	if table == "" || table == DefaultPostsTable {
+		return r, nil
+	}
+	if err := ValidatePostsTable(table); err != nil {
+		return nil, err
+	}
+	routed := *r
+	routed.table = table
+	return &routed, nil
+}
+
+// postsTable is the validated table this repository writes posts to
+func (r *Repository) postsTable() string {
+	if r.table == "" {
+		return DefaultPostsTable
+	}
+	return r.table
+}
+
+func (r *Repository) sharedTable() bool {
+	return r.postsTable() == DefaultPostsTable
+}
+
+// EnsurePostsTable creates a dedicated posts table shaped like posts, with
+// its own id sequence and updated_at trigger. like the migrations it is
+// idempotent, and columns added to posts since the table was created are
+// added to it as well.
+func EnsurePostsTable(table string) error {
+	if table == "" || table == DefaultPostsTable {
+		return nil
+	}
+	if err := ValidatePostsTable(table); err != nil {
+		return err
+	}
+
+	statements := []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s (LIKE posts INCLUDING ALL)`, table),
+		// LIKE copies the default nextval('posts_id_seq'), which would tie the
+		// table to posts and break when posts is dropped
+		fmt.Sprintf(`CREATE SEQUENCE IF NOT EXISTS %[1]s_id_seq OWNED BY %[1]s.id`, table),
+		// guarded like the trigger, since ALTER TABLE locks it exclusively
+		fmt.Sprintf(`DO $$
+		BEGIN
+			IF NOT EXISTS (
+				SELECT 1 FROM information_schema.columns
+				WHERE table_schema = current_schema() AND table_name = '%[1]s'
+				  AND column_name = 'id' AND column_default = 'nextval(''%[1]s_id_seq''::regclass)'
+			) THEN
+				ALTER TABLE %[1]s ALTER COLUMN id SET DEFAULT nextval('%[1]s_id_seq');
+			END IF;
+		END $$`, table),
+		ensureUpdatedAtTrigger(table),
+		// tables copied from posts before post_time became nullable
+		dropPostTimeNotNull(table),
+	}
+
+	q := GetQuerier()
+	for _, stmt := range statements {
+		if _, err := q.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to create table %s: %w", table, err)
+		}
+	}
+	return addMissingColumns(table)
+}
+
+// addMissingColumns copies columns posts has and table lacks. only the type
+// is copied: values are always supplied by the repository's inserts.
+func addMissingColumns(table string) error {
+	q := GetQuerier()
+	rows, err := q.Query(`
+		SELECT a.attname, format_type(a.atttypid, a.atttypmod)
+		FROM pg_attribute a
+		WHERE a.attrelid = 'posts'::regclass AND a.attnum > 0 AND NOT a.attisdropped
+		  AND a.attname NOT IN (
+			SELECT attname FROM pg_attribute
+			WHERE attrelid = $1::regclass AND attnum > 0 AND NOT attisdropped
+		  )`, table)
+	if err != nil {
+		return fmt.Errorf("failed to compare columns of %s: %w", table, err)
+	}
+
+	var columns []string
+	for rows.Next() {
+		var name, dataType string
+		if err := rows.Scan(&name, &dataType); err != nil {
+			rows.Close()
+			return err
+		}
+		columns = append(columns, fmt.Sprintf("ADD COLUMN IF NOT EXISTS %s %s", pq.QuoteIdentifier(name), dataType))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+
+	if _, err := q.Exec(fmt.Sprintf("ALTER TABLE %s %s", table, strings.Join(columns, ", "))); err != nil {
+		return fmt.Errorf("failed to add columns to %s: %w", table, err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package database
+
+import "fmt"
+
+// UpsertStrategy decides what happens to an existing row when a scrape
+// sees a post that is already stored
+type UpsertStrategy int
+
+const (
+	// UpdateScores overwrites points and comments with the scraped values,
+	// for live pages whose numbers are current
+	UpdateScores UpsertStrategy = iota
+	// UpdateIfHigher only ever raises points and comments, for sources that
+	// may lag behind what is already stored
+	UpdateIfHigher
+	// InsertOnly leaves existing rows untouched, for archive pages whose
+	// scores are older than the stored ones
+	InsertOnly
+)
+
+func (s UpsertStrategy) String() string {
+	switch s {
+	case UpdateScores:
+		return "update-scores"
+	case UpdateIfHigher:
+		return "update-if-higher"
+	case InsertOnly:
+		return "insert-only"
+	default:
+		return fmt.Sprintf("UpsertStrategy(%d)", int(s))
+	}
+}
+
+// scoreAssignments returns the SET fragment for points and comments_count
+// given the SQL expressions holding the incoming values
+func (s UpsertStrategy) scoreAssignments(points, comments string) string {
+	if s == UpdateIfHigher {
+		return fmt.Sprintf("points = GREATEST(posts.points, %s),\n\t\t\tcomments_count = GREATEST(posts.comments_count, %s)", points, comments)
+	}
+	return fmt.Sprintf("points = %s,\n\t\t\tcomments_count = %s", points, comments)
+}
+
+func (s UpsertStrategy) conflictClause() string {
+	if s == InsertOnly {
+		return "ON CONFLICT (hn_id) DO NOTHING"
+	}
+	return fmt.Sprintf(`ON CONFLICT (hn_id) DO UPDATE SET
+			title = COALESCE(NULLIF(EXCLUDED.title, ''), posts.title),
+			%s,
+			rank = COALESCE(EXCLUDED.rank, posts.rank),
+			domain = COALESCE(EXCLUDED.domain, posts.domain),
+			post_type = COALESCE(EXCLUDED.post_type, posts.post_type),
+			updated_at = CURRENT_TIMESTAMP`, s.scoreAssignments("EXCLUDED.points", "EXCLUDED.comments_count"))
+}
@@ -0,0 +1,213 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dzmitry-papkou/scraper/internal/models"
+)
+
+func TestScoreAssignments(t *testing.T) {
+	tests := []struct {
+		strategy UpsertStrategy
+		want     []string
+	}{
+		{UpdateScores, []string{"points = $1,", "comments_count = $2"}},
+		{UpdateIfHigher, []string{"points = GREATEST(posts.points, $1),", "comments_count = GREATEST(posts.comments_count, $2)"}},
+		// InsertOnly never reaches an UPDATE, but the fragment stays valid
+		{InsertOnly, []string{"points = $1,", "comments_count = $2"}},
+	}
+	for _, tt := range tests {
+		got := tt.strategy.scoreAssignments("$1", "$2")
+		for _, want := range tt.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("%s scoreAssignments = %q, want it to contain %q", tt.strategy, got, want)
+			}
+		}
+	}
+}
+
+func TestConflictClause(t *testing.T) {
+	tests := []struct {
+		strategy UpsertStrategy
+		want     []string
+		notWant  []string
+	}{
+		{
+			strategy: UpdateScores,
+			want: []string{
+				"ON CONFLICT (hn_id) DO UPDATE SET",
+				"points = EXCLUDED.points,",
+				"comments_count = EXCLUDED.comments_count,",
+				"title = COALESCE(NULLIF(EXCLUDED.title, ''), posts.title)",
+				"updated_at = CURRENT_TIMESTAMP",
+			},
+			notWant: []string{"GREATEST", "DO NOTHING"},
+		},
+		{
+			strategy: UpdateIfHigher,
+			want: []string{
+				"ON CONFLICT (hn_id) DO UPDATE SET",
+				"points = GREATEST(posts.points, EXCLUDED.points),",
+				"comments_count = GREATEST(posts.comments_count, EXCLUDED.comments_count),",
+				"rank = COALESCE(EXCLUDED.rank, posts.rank)",
+			},
+			notWant: []string{"DO NOTHING"},
+		},
+		{
+			strategy: InsertOnly,
+			want:     []string{"ON CONFLICT (hn_id) DO NOTHING"},
+			notWant:  []string{"DO UPDATE", "points"},
+		},
+	}
+	for _, tt := range tests {
+		got := tt.strategy.conflictClause()
+		for _, want := range tt.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("%s conflictClause = %q, want it to contain %q", tt.strategy, got, want)
+			}
+		}
+		for _, notWant := range tt.notWant {
+			if strings.Contains(got, notWant) {
+				t.Errorf("%s conflictClause = %q, should not contain %q", tt.strategy, got, notWant)
+			}
+		}
+	}
+}
+
+func TestUpsertStrategyString(t *testing.T) {
+	tests := []struct {
+		strategy UpsertStrategy
+		want     string
+	}{
+		{UpdateScores, "update-scores"},
+		{UpdateIfHigher, "update-if-higher"},
+		{InsertOnly, "insert-only"},
+		{UpsertStrategy(7), "UpsertStrategy(7)"},
+	}
+	for _, tt := range tests {
+		if got := tt.strategy.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+// testDSNEnv names the Postgres DSN the database tests run against; they
+// are skipped when it is unset. the tests clean up their own rows and
+// table, but point it at a scratch database all the same.
+const testDSNEnv = "SCRAPER_TEST_DSN"
+
+func newTestRepo(t *testing.T) *Repository {
+	t.Helper()
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s not set, skipping database test", testDSNEnv)
+	}
+	if err := InitializeWithURL(dsn, 5, 2, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { Close() })
+	if err := Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	return NewRepository()
+}
+
+// hn ids near the top of the INTEGER range, well clear of real posts
+const testHnIDBase = 2100000000
+
+func storedScores(t *testing.T, table string, hnID int) (points, comments int) {
+	t.Helper()
+	err := GetDB().QueryRow(fmt.Sprintf("SELECT points, comments_count FROM %s WHERE hn_id = $1", table), hnID).
+		Scan(&points, &comments)
+	if err != nil {
+		t.Fatalf("reading post %d from %s: %v", hnID, table, err)
+	}
+	return points, comments
+}
+
+func TestUpsertStrategiesAgainstDatabase(t *testing.T) {
+	shared := newTestRepo(t)
+
+	const routedTable = "upsert_test_posts"
+	if err := EnsurePostsTable(routedTable); err != nil {
+		t.Fatal(err)
+	}
+	routed, err := shared.ForTable(routedTable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		GetDB().Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", routedTable))
+		GetDB().Exec("DELETE FROM posts WHERE hn_id >= $1", testHnIDBase)
+	})
+
+	repos := []struct {
+		table string
+		repo  *Repository
+	}{
+		{"posts", shared},
+		{routedTable, routed},
+	}
+	// the stored post has 50 points and 10 comments
+	tests := []struct {
+		strategy     UpsertStrategy
+		points       int
+		comments     int
+		wantPoints   int
+		wantComments int
+	}{
+		{UpdateScores, 20, 5, 20, 5},
+		{UpdateScores, 80, 15, 80, 15},
+		{UpdateIfHigher, 20, 5, 50, 10},
+		{UpdateIfHigher, 80, 15, 80, 15},
+		{UpdateIfHigher, 80, 5, 80, 10},
+		{InsertOnly, 20, 5, 50, 10},
+		{InsertOnly, 80, 15, 50, 10},
+	}
+	// each case runs through the upsert and through the plain update
+	writes := []struct {
+		name  string
+		write func(r *Repository, post *models.Post, strategy UpsertStrategy) error
+	}{
+		{"UpsertPost", func(r *Repository, post *models.Post, strategy UpsertStrategy) error {
+			inserted, err := r.UpsertPost(post, strategy)
+			if err == nil && inserted {
+				err = fmt.Errorf("existing post %d reported as inserted", post.HnID)
+			}
+			return err
+		}},
+		{"UpdatePostWithStrategy", func(r *Repository, post *models.Post, strategy UpsertStrategy) error {
+			return r.UpdatePostWithStrategy(post, strategy)
+		}},
+	}
+
+	hnID := testHnIDBase
+	for _, target := range repos {
+		for _, write := range writes {
+			for _, tt := range tests {
+				hnID++
+				stored := &models.Post{HnID: hnID, Title: "Stored", Author: "tester", Points: 50, CommentsCount: 10}
+				inserted, err := target.repo.UpsertPost(stored, UpdateScores)
+				if err != nil || !inserted {
+					t.Fatalf("%s: storing post %d = %v, %v", target.table, hnID, inserted, err)
+				}
+
+				scraped := &models.Post{HnID: hnID, Title: "Stored", Author: "tester", Points: tt.points, CommentsCount: tt.comments}
+				if err := write.write(target.repo, scraped, tt.strategy); err != nil {
+					t.Errorf("%s %s %s: %v", target.table, write.name, tt.strategy, err)
+					continue
+				}
+
+				points, comments := storedScores(t, target.table, hnID)
+				if points != tt.wantPoints || comments != tt.wantComments {
+					t.Errorf("%s %s %s with %d/%d: stored %d/%d, want %d/%d", target.table, write.name, tt.strategy,
+						tt.points, tt.comments, points, comments, tt.wantPoints, tt.wantComments)
+				}
+			}
+		}
+	}
+}
@@ -5,17 +5,28 @@ import (
 )
 
 type Post struct {
-	ID            int       `db:"id"`
-	HnID          int       `db:"hn_id"`
-	Title         string    `db:"title"`
-	URL           string    `db:"url"`
-	Author        string    `db:"author"`
-	Points        int       `db:"points"`
-	CommentsCount int       `db:"comments_count"`
-	PostTime      time.Time `db:"post_time"`
-	ScrapedAt     time.Time `db:"scraped_at"`
-	CreatedAt     time.Time `db:"created_at"`
-	UpdatedAt     time.Time `db:"updated_at"`
+	ID            int       `db:"id" json:"id"`
+	HnID          int       `db:"hn_id" json:"hn_id"`
+	Title         string    `db:"title" json:"title"`
+	URL           string    `db:"url" json:"url"`
+	Author        string    `db:"author" json:"author"`
+	Points        int       `db:"points" json:"points"`
+	CommentsCount int       `db:"comments_count" json:"comments_count"`
+	Source        string    `db:"source" json:"source,omitempty"`
+	// front-page position when scraped from a ranked listing, 0 if unknown
+	Rank          int       `db:"rank" json:"rank,omitempty"`
+	// derived by post processors, empty when not configured
+	Domain        string    `db:"domain" json:"domain,omitempty"`
+	PostType      string    `db:"post_type" json:"post_type,omitempty"`
+	// zero when unknown, stored as NULL
+	PostTime      time.Time `db:"post_time" json:"post_time"`
+	ScrapedAt     time.Time `db:"scraped_at" json:"scraped_at"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`
+	// set by the parser when the metadata row was missing, so score, author
+	// and time are unknown; partial posts are only ever inserted, never used
+	// to update a stored row or record history
+	Partial       bool      `db:"-" json:"-"`
 }
 
 type PostHistory struct {
@@ -26,14 +37,138 @@ type PostHistory struct {
 	RecordedAt    time.Time `db:"recorded_at"`
 }
 
+type TitleChange struct {
+	ID        int       `db:"id"`
+	PostID    int       `db:"post_id"`
+	HnID      int       `db:"hn_id"`
+	OldTitle  string    `db:"old_title"`
+	NewTitle  string    `db:"new_title"`
+	ChangedAt time.Time `db:"changed_at"`
+}
+
+// TrackedPost summarises how long a post has been followed in post_history
+type TrackedPost struct {
+	Post      Post
+	Snapshots int
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// ThresholdPost is a post and the first history snapshot at which it had
+// reached a score threshold
+type ThresholdPost struct {
+	Post      Post
+	ReachedAt time.Time
+	// time from submission to ReachedAt; an upper bound, since the score
+	// may have crossed the threshold any time after the previous snapshot
+	Elapsed time.Duration
+}
+
+// RepostGroup is a link submitted under more than one hn_id, with posts
+// ordered by points so the first one is the best-scoring submission
+type RepostGroup struct {
+	NormalizedURL string
+	Posts         []Post
+}
+
+// IDRange is an inclusive span of hn_ids
+type IDRange struct {
+	Start int
+	End   int
+}
+
+// DailyCount is the number of posts stored on one day
+type DailyCount struct {
+	Date  string
+	Posts int
+}
+
+// DailyJobStats counts the scraping jobs that finished on one day by outcome
+type DailyJobStats struct {
+	Date      string
+	Completed int
+	Partial   int
+	Failed    int
+}
+
+// Finished is the number of jobs that reached a final status
+func (d DailyJobStats) Finished() int {
+	return d.Completed + d.Partial + d.Failed
+}
+
+// SuccessRate is the fraction of finished jobs that completed cleanly, or
+// zero on a day without any
+func (d DailyJobStats) SuccessRate() float64 {
+	if d.Finished() == 0 {
+		return 0
+	}
+	return float64(d.Completed) / float64(d.Finished())
+}
+
+// DurationStats summarizes how long finished scraping jobs took. Mode is
+// empty for the row covering every job.
+type DurationStats struct {
+	Mode string
+	Jobs int
+	Min  time.Duration
+	Avg  time.Duration
+	P95  time.Duration
+	Max  time.Duration
+}
+
+// JobAnomaly is a finished scraping job whose post count or duration
+// strayed from the runs of the same mode before it. the means and
+// deviations describe that trailing baseline.
+type JobAnomaly struct {
+	JobID     int
+	StartedAt time.Time
+	Mode      string
+	Status    string
+
+	Posts       int
+	PostsMean   float64
+	PostsStdDev float64
+	PostsOff    bool
+
+	Duration       time.Duration
+	DurationMean   time.Duration
+	DurationStdDev time.Duration
+	DurationOff    bool
+}
+
+// scraping_jobs.status values. partial means the run finished but some
+// pages or inserts failed along the way.
+const (
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusPartial   = "partial"
+	JobStatusFailed    = "failed"
+)
 
 type ScrapingJob struct {
-	ID           int        `db:"id"`
-	StartedAt    time.Time  `db:"started_at"`
-	CompletedAt  *time.Time `db:"completed_at"`
-	Status       string     `db:"status"`
-	PostsScraped int        `db:"posts_scraped"`
-	ErrorMessage *string    `db:"error_message"`
+	ID           int                 `db:"id"`
+	StartedAt    time.Time           `db:"started_at"`
+	CompletedAt  *time.Time          `db:"completed_at"`
+	Status       string              `db:"status"`
+	PostsScraped int                 `db:"posts_scraped"`
+	ErrorMessage *string             `db:"error_message"`
+	Details      *ScrapingJobDetails `db:"details"`
+}
+
+// ScrapingJobDetails is stored as JSON in scraping_jobs.details
+type ScrapingJobDetails struct {
+	// config name of the scraper that ran the job, empty on older rows
+	Scraper       string   `json:"scraper,omitempty"`
+	Mode          string   `json:"mode"`
+	NewPosts      int      `json:"new_posts"`
+	UpdatedPosts  int      `json:"updated_posts"`
+	DeletedPosts  int      `json:"deleted_posts"`
+	PagesScraped  int      `json:"pages_scraped"`
+	LastKnownID   int      `json:"last_known_id"`
+	HighestIDSeen int      `json:"highest_id_seen"`
+	Errors        []string `json:"errors,omitempty"`
+	StopReason    string   `json:"stop_reason,omitempty"`
+	PostsPerPage  []int    `json:"posts_per_page,omitempty"`
 }
 
 type AnalysisResult struct {
@@ -0,0 +1,47 @@
+package scraper
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/dzmitry-papkou/scraper/internal/models"
+)
+
+// PointsAlert reports a post that crossed its scraper's alert_points
+type PointsAlert struct {
+	Scraper   string
+	Post      models.Post
+	Threshold int
+	Time      time.Time
+}
+
+// posts already alerted on in this process, shared by every scraper so a
+// post seen again on the next run (or by scrape-new) isn't reported twice
+var alerted = struct {
+	sync.Mutex
+	ids map[int]bool
+}{ids: make(map[int]bool)}
+
+// checkPointsAlert returns an alert the first time post is seen above
+// threshold; a zero threshold disables alerting
+func checkPointsAlert(scraperName string, threshold int, post *models.Post) (PointsAlert, bool) {
+	if threshold <= 0 || post.Points < threshold {
+		return PointsAlert{}, false
+	}
+
+	alerted.Lock()
+	defer alerted.Unlock()
+	if alerted.ids[post.HnID] {
+		return PointsAlert{}, false
+	}
+	alerted.ids[post.HnID] = true
+
+	log.Printf("Alert: post %d reached %d points (threshold %d)", post.HnID, post.Points, threshold)
+	return PointsAlert{
+		Scraper:   scraperName,
+		Post:      *post,
+		Threshold: threshold,
+		Time:      time.Now(),
+	}, true
+}
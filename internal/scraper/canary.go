@@ -0,0 +1,87 @@
+package scraper
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/dzmitry-papkou/scraper/internal/models"
+)
+
+// a run parsing fewer than parseRegressionRatio of the usual posts per page
+// most likely means the markup changed under the selectors
+const (
+	parseBaselineRuns    = 5
+	parseRegressionRatio = 0.5
+	// jobs of other scrapers and modes, and jobs saved without page counts
+	// (older rows, one-off scrapes), are skipped, so look further back to
+	// find enough runs
+	parseHistoryLookback = 100
+)
+
+// checkParseRegression compares this run's posts per page with the median of
+// the last few recorded runs of the same scraper and mode and sets result.ParseWarning when it dropped
+// below half. must run before the result is saved so it isn't its own baseline.
+func (s *SmartScraper) checkParseRegression(result *ScrapingResult) {
+	current, ok := averagePerPage(result.PostsPerPage)
+	if !ok {
+		return
+	}
+
+	history, err := s.repo.GetScrapingHistory(parseHistoryLookback)
+	if err != nil {
+		log.Printf("Warning: Could not load scraping history for the parse check: %v", err)
+		return
+	}
+
+	baseline, runs := parseBaseline(history, s.config.Name, string(result.Mode))
+	if runs < parseBaselineRuns {
+		return
+	}
+
+	if current < baseline*parseRegressionRatio {
+		result.ParseWarning = fmt.Sprintf("parsed %.1f posts/page, the last %d runs had a median of %.1f; selectors may be out of date",
+			current, runs, baseline)
+		log.Printf("Warning: %s", result.ParseWarning)
+	}
+}
+
+// parseBaseline returns the median posts per page over the most recent
+// parseBaselineRuns jobs of the given scraper and mode that recorded page
+// counts. other sites and modes parse a different number of posts per page,
+// so they can't tell whether this scraper's selectors broke.
+func parseBaseline(history []models.ScrapingJob, scraper, mode string) (float64, int) {
+	var averages []float64
+	for _, job := range history {
+		if job.Details == nil || job.Details.Scraper != scraper || job.Details.Mode != mode {
+			continue
+		}
+		if avg, ok := averagePerPage(job.Details.PostsPerPage); ok {
+			averages = append(averages, avg)
+		}
+		if len(averages) == parseBaselineRuns {
+			break
+		}
+	}
+	if len(averages) == 0 {
+		return 0, 0
+	}
+
+	sort.Float64s(averages)
+	mid := len(averages) / 2
+	if len(averages)%2 == 0 {
+		return (averages[mid-1] + averages[mid]) / 2, len(averages)
+	}
+	return averages[mid], len(averages)
+}
+
+func averagePerPage(counts []int) (float64, bool) {
+	if len(counts) == 0 {
+		return 0, false
+	}
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	return float64(total) / float64(len(counts)), true
+}
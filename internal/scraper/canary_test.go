@@ -0,0 +1,39 @@
+package scraper
+
+import (
+	"testing"
+
+	"github.com/dzmitry-papkou/scraper/internal/models"
+)
+
+func TestParseBaselineUsesSameScraperAndMode(t *testing.T) {
+	job := func(scraper, mode string, perPage ...int) models.ScrapingJob {
+		return models.ScrapingJob{Details: &models.ScrapingJobDetails{
+			Scraper:      scraper,
+			Mode:         mode,
+			PostsPerPage: perPage,
+		}}
+	}
+	history := []models.ScrapingJob{
+		job("lobsters", "latest", 25),
+		job("hackernews", "full", 30, 30),
+		job("hackernews", "latest", 30),
+		{},
+		job("", "latest", 5),
+		job("hackernews", "latest", 28, 30),
+		job("lobsters", "latest", 25),
+		job("hackernews", "latest", 40),
+	}
+
+	baseline, runs := parseBaseline(history, "hackernews", "latest")
+	if runs != 3 {
+		t.Fatalf("parseBaseline() runs = %d, want 3", runs)
+	}
+	if baseline != 30 {
+		t.Errorf("parseBaseline() baseline = %.1f, want 30.0", baseline)
+	}
+
+	if _, runs := parseBaseline(history, "reddit", "latest"); runs != 0 {
+		t.Errorf("parseBaseline() for a scraper without jobs runs = %d, want 0", runs)
+	}
+}
@@ -0,0 +1,67 @@
+package scraper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// ArchiveCheckpoint records how far a full archive scrape got, so an
+// interrupted run can continue from the next page
+type ArchiveCheckpoint struct {
+	Scraper   string    `json:"scraper"`
+	LastPage  int       `json:"last_page"`
+	HighestID int       `json:"highest_id"`
+	SeenIDs   int       `json:"seen_ids"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DefaultCheckpointPath is where scrape-all keeps the checkpoint for a scraper
+func DefaultCheckpointPath(scraperName string) string {
+	return fmt.Sprintf("archive-%s.checkpoint.json", scraperName)
+}
+
+// LoadCheckpoint reads a checkpoint file, returning nil when there is none
+func LoadCheckpoint(path string) (*ArchiveCheckpoint, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+
+	var checkpoint ArchiveCheckpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint %s: %w", path, err)
+	}
+	return &checkpoint, nil
+}
+
+// save writes the checkpoint through a temporary file so an interrupt
+// mid-write leaves the previous checkpoint intact
+func (c *ArchiveCheckpoint) save(path string) error {
+	c.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return nil
+}
+
+func removeCheckpoint(path string) {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("Warning: Could not remove checkpoint %s: %v", path, err)
+	}
+}
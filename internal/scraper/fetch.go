@@ -0,0 +1,110 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/dzmitry-papkou/scraper/internal/config"
+)
+
+const defaultMaxResponseSize = 10 << 20
+
+var ErrResponseTooLarge = errors.New("response too large")
+
+var (
+	saveHTML     bool
+	debugHTMLDir = "./debug"
+)
+
+// SetSaveHTML makes every fetch write the raw page under dir before it is
+// parsed. dir is also where a failed self-test leaves the offending page.
+func SetSaveHTML(enabled bool, dir string) {
+	saveHTML = enabled
+	if dir != "" {
+		debugHTMLDir = dir
+	}
+}
+
+// fetchDocument requests pageURL through client with the scraper's
+// configured headers and parses the response body. cancelling ctx aborts
+// the request.
+func fetchDocument(ctx context.Context, client *http.Client, scraperConfig *config.ScraperConfig, pageURL string) (*goquery.Document, error) {
+	body, err := fetchBody(ctx, client, scraperConfig, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse page: %w", err)
+	}
+
+	return doc, nil
+}
+
+func fetchBody(ctx context.Context, client *http.Client, scraperConfig *config.ScraperConfig, pageURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	for name, value := range scraperConfig.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch page: %w", err)
+	}
+	defer resp.Body.Close()
+
+	maxSize := scraperConfig.MaxResponseSize
+	if maxSize <= 0 {
+		maxSize = defaultMaxResponseSize
+	}
+	if resp.ContentLength > maxSize {
+		return nil, fmt.Errorf("%w: %d bytes exceeds the %d byte limit", ErrResponseTooLarge, resp.ContentLength, maxSize)
+	}
+
+	// read one byte past the cap so an oversized body is detected rather
+	// than silently truncated into a half-parsed page
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read page: %w", err)
+	}
+	if int64(len(body)) > maxSize {
+		return nil, fmt.Errorf("%w: exceeds the %d byte limit", ErrResponseTooLarge, maxSize)
+	}
+
+	if saveHTML {
+		if path, err := saveDebugHTML(scraperConfig.Name, body); err != nil {
+			log.Printf("Warning: Could not save page HTML: %v", err)
+		} else {
+			log.Printf("Saved %s to %s", pageURL, path)
+		}
+	}
+
+	return body, nil
+}
+
+// saveDebugHTML writes body to a timestamped file in the debug directory
+func saveDebugHTML(scraperName string, body []byte) (string, error) {
+	if err := os.MkdirAll(debugHTMLDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create debug directory: %w", err)
+	}
+
+	name := fmt.Sprintf("%s-%s.html", scraperName, time.Now().Format("20060102-150405.000"))
+	path := filepath.Join(debugHTMLDir, name)
+	if err := os.WriteFile(path, body, 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
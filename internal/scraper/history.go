@@ -0,0 +1,74 @@
+package scraper
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/dzmitry-papkou/scraper/internal/database"
+	"github.com/dzmitry-papkou/scraper/internal/models"
+)
+
+// historySnapshot is a post_history row waiting to be written
+type historySnapshot struct {
+	repo     *database.Repository
+	postID   int
+	points   int
+	comments int
+}
+
+// snapshots recorded but not yet written, shared by every scraper so a
+// single FlushHistory on shutdown covers all of them
+var pendingHistory = struct {
+	sync.Mutex
+	snapshots []historySnapshot
+}{}
+
+// recordHistory queues a score snapshot; it is written by the next
+// FlushHistory, which every scrape run calls before returning
+func recordHistory(repo *database.Repository, postID, points, comments int) {
+	pendingHistory.Lock()
+	defer pendingHistory.Unlock()
+	pendingHistory.snapshots = append(pendingHistory.snapshots, historySnapshot{
+		repo:     repo,
+		postID:   postID,
+		points:   points,
+		comments: comments,
+	})
+}
+
+// recordStoredPost queues a snapshot of a post the repository just inserted
+// or updated, whose id and scores are then the stored row's. posts that
+// weren't written keep a zero id and get none.
+func recordStoredPost(repo *database.Repository, post *models.Post) {
+	if post.ID > 0 && !post.Partial {
+		recordHistory(repo, post.ID, post.Points, post.CommentsCount)
+	}
+}
+
+// FlushHistory writes every queued score snapshot. failed writes are
+// logged one by one and summarised in the returned error; they are not
+// retried, since the next run records a fresh snapshot anyway.
+func FlushHistory() error {
+	pendingHistory.Lock()
+	snapshots := pendingHistory.snapshots
+	pendingHistory.snapshots = nil
+	pendingHistory.Unlock()
+
+	failed := 0
+	var firstErr error
+	for _, snap := range snapshots {
+		if err := snap.repo.InsertPostHistory(snap.postID, snap.points, snap.comments); err != nil {
+			log.Printf("Failed to record history for post id %d: %v", snap.postID, err)
+			failed++
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d history snapshots failed: %w", failed, len(snapshots), firstErr)
+	}
+	return nil
+}
@@ -1,11 +1,15 @@
 package scraper
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/dzmitry-papkou/scraper/internal/config"
 	"github.com/dzmitry-papkou/scraper/internal/database"
 )
 
@@ -14,19 +18,179 @@ type ScraperJob struct {
 	Ticker   *time.Ticker
 	StopChan chan bool
 	IsActive bool
+	Interval time.Duration
+	Started  time.Time
+
+	lastRun time.Time
+	// cancels a scrape still in flight when the job is stopped
+	cancel context.CancelFunc
+	ctx    context.Context
+}
+
+// ScrapeEvent reports the outcome of one scheduled scrape
+type ScrapeEvent struct {
+	Scraper string
+	Time    time.Time
+	Count   int
+	Err     error
+	Alerts  []PointsAlert
 }
 
 type MultiScheduler struct {
 	repo     *database.Repository
 	scrapers map[string]*ScraperJob
+	events   chan ScrapeEvent
 	mu       sync.RWMutex
+
+	maxActive int
+	// holds a slot for each scrape in progress, so scrapers sharing the
+	// connection pool don't all hit it at once
+	scrapeSlots chan struct{}
+
+	scrapeTimeout time.Duration
+	firstRetries  int
+	// tracks scrape goroutines so Shutdown can wait for them to unwind
+	running sync.WaitGroup
 }
 
 func NewMultiScheduler(repo *database.Repository) *MultiScheduler {
+	appConfig := config.Get().App
+	slots := appConfig.MaxConcurrentScrapes
+	if slots < 1 {
+		slots = 1
+	}
 	return &MultiScheduler{
-		repo:     repo,
-		scrapers: make(map[string]*ScraperJob),
+		repo:        repo,
+		scrapers:    make(map[string]*ScraperJob),
+		events:      make(chan ScrapeEvent, 100),
+		maxActive:   appConfig.MaxSchedulers,
+		scrapeSlots: make(chan struct{}, slots),
+
+		scrapeTimeout: appConfig.ScrapeTimeout,
+		firstRetries:  appConfig.FirstScrapeRetries,
+	}
+}
+
+// Events streams scrape outcomes so the caller decides how to render them
+// instead of the goroutines writing over the interactive prompt
+func (s *MultiScheduler) Events() <-chan ScrapeEvent {
+	return s.events
+}
+
+// firstScrapeRetryDelay is the pause before retrying a failed first scrape
+const firstScrapeRetryDelay = 30 * time.Second
+
+// runJob runs job until it is stopped: a scrape straight away, then one
+// per tick. a failed first scrape is retried, so a scraper started during
+// a network blip doesn't sit idle for a whole interval. retries and ticks
+// share this goroutine, so two runs of the same job never overlap.
+func (s *MultiScheduler) runJob(name string, job *ScraperJob) {
+	defer s.running.Done()
+
+	retry := time.NewTimer(0)
+	defer retry.Stop()
+	retriesLeft := s.firstRetries
+
+	for {
+		select {
+		case <-retry.C:
+			err := s.runScrape(name, job)
+			if err != nil && retriesLeft > 0 && job.ctx.Err() == nil {
+				log.Printf("First scrape of %s failed, retrying in %s (%d/%d)",
+					name, firstScrapeRetryDelay, s.firstRetries-retriesLeft+1, s.firstRetries)
+				retriesLeft--
+				retry.Reset(firstScrapeRetryDelay)
+			}
+		case <-job.Ticker.C:
+			// a successful tick makes a pending retry pointless
+			if err := s.runScrape(name, job); err == nil {
+				retry.Stop()
+			}
+		case <-job.StopChan:
+			return
+		}
+
+		// a tick that fired while the run was in flight is stale; wait for
+		// the next one rather than starting straight away
+		select {
+		case <-job.Ticker.C:
+		default:
+		}
+	}
+}
+
+// a run must finish this long before the next tick so runs never overlap
+const scheduleMargin = 5 * time.Second
+
+// runBudget is how long one run of job may take: the scrape timeout, or
+// the interval less a margin when that is shorter. the second reports
+// whether the interval is the binding limit.
+func (s *MultiScheduler) runBudget(job *ScraperJob) (time.Duration, bool) {
+	margin := scheduleMargin
+	if job.Interval <= 2*margin {
+		margin = job.Interval / 2
+	}
+	budget := job.Interval - margin
+	if s.scrapeTimeout > 0 && s.scrapeTimeout < budget {
+		return s.scrapeTimeout, false
 	}
+	return budget, true
+}
+
+func (s *MultiScheduler) runScrape(name string, job *ScraperJob) error {
+	// ReloadScrapers may swap the scraper between runs
+	s.mu.RLock()
+	scraperInstance := job.Scraper
+	s.mu.RUnlock()
+	if !scraperInstance.GetConfig().ActiveAt(time.Now()) {
+		log.Printf("Skipping %s: outside active hours (%s UTC)", name, scraperInstance.GetConfig().ActiveHours)
+		return nil
+	}
+
+	// the deadline starts at the tick, so time spent waiting for a slot
+	// counts against it and a late run still ends before the next tick
+	budget, byInterval := s.runBudget(job)
+	ctx, cancel := context.WithTimeout(job.ctx, budget)
+	defer cancel()
+
+	// a scraper whose turn comes while the slots are taken waits rather
+	// than skipping the tick, unless it is stopped in the meantime
+	select {
+	case s.scrapeSlots <- struct{}{}:
+	case <-ctx.Done():
+		if job.ctx.Err() != nil {
+			return job.ctx.Err()
+		}
+		log.Printf("Skipping %s: no scrape slot free before the next tick", name)
+		return ctx.Err()
+	}
+	defer func() { <-s.scrapeSlots }()
+
+	s.mu.Lock()
+	job.lastRun = time.Now()
+	s.mu.Unlock()
+
+	count, err := scraperInstance.ScrapeOnceContext(ctx)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) && byInterval:
+		err = fmt.Errorf("scrape exceeded interval %s and was cancelled: %w", job.Interval, err)
+		log.Printf("Scrape of %s exceeded interval %s, cancelled until the next tick", name, job.Interval)
+	case errors.Is(err, context.DeadlineExceeded):
+		err = fmt.Errorf("scrape timed out after %s: %w", budget, err)
+		log.Printf("Scrape of %s timed out after %s", name, budget)
+	case errors.Is(err, context.Canceled):
+		// stopped by the user; nothing worth reporting
+		return err
+	}
+	event := ScrapeEvent{Scraper: name, Time: time.Now(), Count: count, Err: err, Alerts: scraperInstance.TakeAlerts()}
+
+	// never block a scrape goroutine on a reader that isn't draining
+	select {
+	case s.events <- event:
+	default:
+		log.Printf("Dropped scrape event for %s (nobody listening)", name)
+	}
+	return err
 }
 
 func (s *MultiScheduler) StartScraper(name string, interval time.Duration) error {
@@ -37,49 +201,65 @@ func (s *MultiScheduler) StartScraper(name string, interval time.Duration) error
 		return fmt.Errorf("scraper %s is already running", name)
 	}
 
+	if interval <= 0 {
+		return fmt.Errorf("invalid interval %s for scraper %s", interval, name)
+	}
+	if minInterval := config.Get().App.MinInterval; interval < minInterval {
+		return fmt.Errorf("interval %s for scraper %s is below min_interval %s", interval, name, minInterval)
+	}
+
+	if s.maxActive > 0 && s.activeCount() >= s.maxActive {
+		return fmt.Errorf("cannot start %s: %d scrapers already running (max_schedulers)", name, s.maxActive)
+	}
+
 	scraperInstance, err := NewGenericScraper(s.repo, name)
 	if err != nil {
 		return fmt.Errorf("failed to create scraper %s: %w", name, err)
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
 	job := &ScraperJob{
 		Scraper:  scraperInstance,
 		Ticker:   time.NewTicker(interval),
 		StopChan: make(chan bool),
 		IsActive: true,
+		Interval: interval,
+		Started:  time.Now(),
+		ctx:      ctx,
+		cancel:   cancel,
 	}
 
 	s.scrapers[name] = job
 
-	go func() {
-		count, err := scraperInstance.ScrapeOnce()
-		if err != nil {
-			log.Printf("Error scraping %s: %v", name, err)
-		} else {
-			fmt.Printf("✓ Auto-scraped %d posts from %s\n", count, name)
-		}
-	}()
-
-	go func() {
-		for {
-			select {
-			case <-job.Ticker.C:
-				count, err := scraperInstance.ScrapeOnce()
-				if err != nil {
-					log.Printf("Auto-scrape error for %s: %v", name, err)
-				} else {
-					fmt.Printf("\n✓ Auto-scraped %d posts from %s\n➜ ", count, name)
-				}
-			case <-job.StopChan:
-				return
-			}
-		}
-	}()
+	s.running.Add(1)
+	go s.runJob(name, job)
 
 	log.Printf("Started scheduler for %s with interval %s", name, interval)
 	return nil
 }
 
+// ReloadScrapers rebuilds the scraper of every active job from the current
+// config, so an imported config applies from each job's next run. a job
+// whose scraper no longer builds keeps the old one, and is reported.
+func (s *MultiScheduler) ReloadScrapers() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+	for name, job := range s.scrapers {
+		if !job.IsActive {
+			continue
+		}
+		fresh, err := NewGenericScraper(s.repo, name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s keeps its previous config: %w", name, err))
+			continue
+		}
+		job.Scraper = fresh
+	}
+	return errors.Join(errs...)
+}
+
 func (s *MultiScheduler) StopScraper(name string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -91,6 +271,7 @@ func (s *MultiScheduler) StopScraper(name string) error {
 
 	job.Ticker.Stop()
 	close(job.StopChan)
+	job.cancel()
 	job.IsActive = false
 
 	log.Printf("Stopped scheduler for %s", name)
@@ -105,12 +286,39 @@ func (s *MultiScheduler) StopAll() {
 		if job.IsActive {
 			job.Ticker.Stop()
 			close(job.StopChan)
+			job.cancel()
 			job.IsActive = false
 			log.Printf("Stopped scheduler for %s", name)
 		}
 	}
 }
 
+// Shutdown stops every scraper and waits up to grace for scrapes in
+// flight to unwind. it reports false when some were still running, which
+// can only be a database call outlasting the cancelled request. queued
+// score history is flushed either way.
+func (s *MultiScheduler) Shutdown(grace time.Duration) bool {
+	s.StopAll()
+
+	done := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(done)
+	}()
+
+	finished := true
+	select {
+	case <-done:
+	case <-time.After(grace):
+		finished = false
+	}
+
+	if err := FlushHistory(); err != nil {
+		log.Printf("Shutdown: %v", err)
+	}
+	return finished
+}
+
 func (s *MultiScheduler) IsActive(name string) bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -119,6 +327,17 @@ func (s *MultiScheduler) IsActive(name string) bool {
 	return exists && job.IsActive
 }
 
+// activeCount is the number of running scrapers; callers hold s.mu
+func (s *MultiScheduler) activeCount() int {
+	count := 0
+	for _, job := range s.scrapers {
+		if job.IsActive {
+			count++
+		}
+	}
+	return count
+}
+
 func (s *MultiScheduler) GetActiveScrapers() []string {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -130,4 +349,48 @@ func (s *MultiScheduler) GetActiveScrapers() []string {
 		}
 	}
 	return active
-}
\ No newline at end of file
+}
+// ScheduleEntry describes when an active scraper last ran and runs next
+type ScheduleEntry struct {
+	Scraper  string
+	Interval time.Duration
+	LastRun  time.Time
+	NextRun  time.Time
+}
+
+// Schedule lists the active scrapers ordered by their next run
+func (s *MultiScheduler) Schedule() []ScheduleEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	var entries []ScheduleEntry
+	for name, job := range s.scrapers {
+		if !job.IsActive {
+			continue
+		}
+
+		base := job.lastRun
+		if base.IsZero() {
+			base = job.Started
+		}
+		// ticks skipped outside active hours don't update lastRun, but the
+		// ticker keeps going, so step forward to the next future tick
+		next := base.Add(job.Interval)
+		for next.Before(now) {
+			next = next.Add(job.Interval)
+		}
+
+		entries = append(entries, ScheduleEntry{
+			Scraper:  name,
+			Interval: job.Interval,
+			LastRun:  job.lastRun,
+			NextRun:  next,
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].NextRun.Before(entries[j].NextRun)
+	})
+	return entries
+}
@@ -6,21 +6,71 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/dzmitry-papkou/scraper/internal/config"
 	"github.com/dzmitry-papkou/scraper/internal/models"
 )
 
-type Parser struct{}
+type Parser struct {
+	config *config.ScraperConfig
+
+	itemSelector   string
+	titleSelector  string
+	pointsSelector string
+	authorSelector string
+	rankSelector   string
+	metadataRow    string
+	timeLayouts    []string
+}
 
 func NewParser() *Parser {
-	return &Parser{}
+	return &Parser{
+		itemSelector:   defaultItemSelector,
+		titleSelector:  defaultTitleSelector,
+		pointsSelector: defaultPointsSelector,
+		authorSelector: defaultAuthorSelector,
+		rankSelector:   defaultRankSelector,
+		metadataRow:    defaultMetadataRow,
+		timeLayouts:    defaultTimeLayouts,
+	}
+}
+
+func NewParserWithConfig(scraperConfig *config.ScraperConfig) *Parser {
+	p := NewParser()
+	p.config = scraperConfig
+
+	if scraperConfig.Selectors.Item != "" {
+		p.itemSelector = scraperConfig.Selectors.Item
+	}
+	if scraperConfig.Selectors.MetadataRow != "" {
+		p.metadataRow = scraperConfig.Selectors.MetadataRow
+	}
+	if scraperConfig.Selectors.Title != "" {
+		p.titleSelector = scraperConfig.Selectors.Title
+	}
+	if scraperConfig.Selectors.Points != "" {
+		p.pointsSelector = scraperConfig.Selectors.Points
+	}
+	if scraperConfig.Selectors.Author != "" {
+		p.authorSelector = scraperConfig.Selectors.Author
+	}
+	if scraperConfig.Selectors.Rank != "" {
+		p.rankSelector = scraperConfig.Selectors.Rank
+	}
+	if len(scraperConfig.TimeLayouts) > 0 {
+		p.timeLayouts = append(append([]string{}, scraperConfig.TimeLayouts...), defaultTimeLayouts...)
+	}
+
+	return p
 }
 
 func (p *Parser) ParseDocument(doc *goquery.Document) ([]models.Post, error) {
 	var posts []models.Post
 
-	doc.Find("tr.athing").Each(func(i int, s *goquery.Selection) {
+	doc.Find(p.itemSelector).Each(func(i int, s *goquery.Selection) {
 		post, err := p.parsePost(s)
 		if err != nil {
 			log.Printf("Error parsing post #%d: %v", i+1, err)
@@ -47,32 +97,65 @@ func (p *Parser) parsePost(s *goquery.Selection) (models.Post, error) {
 	}
 	post.HnID = hnID
 
-	// title and url from .titleline
-	titleline := s.Find(".titleline")
-	titleLink := titleline.Find("a").First()
-	post.Title = strings.TrimSpace(titleLink.Text())
-	post.URL, _ = titleLink.Attr("href")
+	if p.config != nil {
+		post.Source = p.config.Name
+	}
+
+	// title and url from the first title selector that matches
+	if titleLink := firstMatch(s, p.titleSelector); titleLink != nil {
+		post.Title = strings.TrimSpace(titleLink.Text())
+		post.URL, _ = titleLink.Attr("href")
+	}
+
+	if clean, repaired := sanitizeUTF8(post.Title); repaired {
+		log.Printf("Post %d title was not valid UTF-8, stored as %q", post.HnID, clean)
+		post.Title = clean
+	}
 
 	if post.URL != "" && !strings.HasPrefix(post.URL, "http") {
 		post.URL = "https://news.ycombinator.com/" + post.URL
 	}
 
-	// metadata from the next <tr> (subtext row)
-	metaRow := s.Next()
+	// rank ("1.") is only shown on ranked listings; item pages leave it 0
+	if rank := firstMatch(s, p.rankSelector); rank != nil {
+		if n, ok := parseIntLoose(rank.Text()); ok {
+			post.Rank = n
+		}
+	}
+
+	metaRow := p.findMetadataRow(s)
 	if metaRow.Length() == 0 {
-		return post, fmt.Errorf("no metadata row found")
+		if p.config != nil && p.config.SkipIncomplete {
+			return post, fmt.Errorf("no metadata row found (metadata_row: %q)", p.metadataRow)
+		}
+		// a partial record beats losing the post. it is stored insert-only
+		// with no post_time, so it never overwrites a real score
+		log.Printf("Post %d has no metadata row (metadata_row: %q), storing it without score, author or time",
+			post.HnID, p.metadataRow)
+		post.Partial = true
+		post.Author = "unknown"
+		post.ScrapedAt = time.Now()
+		return post, nil
 	}
 
+	// listings wrap the metadata in .subtext; other layouts (comment
+	// headers) keep it directly in the located element
 	subtext := metaRow.Find(".subtext")
+	if subtext.Length() == 0 {
+		subtext = metaRow
+	}
 
 	// points
-	scoreText := subtext.Find(".score").Text()
-	if scoreText != "" {
-		fmt.Sscanf(scoreText, "%d points", &post.Points)
+	if score := firstMatch(subtext, p.pointsSelector); score != nil {
+		if n, ok := parseIntLoose(score.Text()); ok {
+			post.Points = n
+		}
 	}
 
 	// author
-	post.Author = strings.TrimSpace(subtext.Find(".hnuser").Text())
+	if author := firstMatch(subtext, p.authorSelector); author != nil {
+		post.Author = p.normalizeAuthor(author.Text())
+	}
 	if post.Author == "" {
 		post.Author = "unknown"
 	}
@@ -83,18 +166,12 @@ func (p *Parser) parsePost(s *goquery.Selection) (models.Post, error) {
 		timeStr, hasTitle := ageElement.Attr("title")
 		
 		if hasTitle && timeStr != "" {
-			parts := strings.Fields(timeStr)
-			if len(parts) > 0 {
-				timeToParse := parts[0]
-				
-				// time ISO format
-				if t, err := time.Parse("2006-01-02T15:04:05", timeToParse); err == nil {
-					post.PostTime = t
-				} else {
-					// fallback to relative time
-					ageText := strings.TrimSpace(ageElement.Text())
-					post.PostTime = p.parseRelativeTime(ageText)
-				}
+			if t, ok := p.parseTimestamp(timeStr); ok {
+				post.PostTime = t
+			} else {
+				// fallback to relative time
+				ageText := strings.TrimSpace(ageElement.Text())
+				post.PostTime = p.parseRelativeTime(ageText)
 			}
 		} else {
 			// relative time from text 
@@ -116,6 +193,66 @@ func (p *Parser) parsePost(s *goquery.Selection) (models.Post, error) {
 	return post, nil
 }
 
+// findMetadataRow locates the element holding score, author and age
+// relative to the item row, as configured by metadata_row:
+//
+//	next           the following sibling row (listings)
+//	self           the item row itself
+//	next:<sel>     the first following sibling matching sel
+//	closest:<sel>  the nearest ancestor matching sel
+func (p *Parser) findMetadataRow(s *goquery.Selection) *goquery.Selection {
+	mode, selector, _ := strings.Cut(p.metadataRow, ":")
+	switch mode {
+	case "self":
+		return s
+	case "next":
+		if selector == "" {
+			return s.Next()
+		}
+		return s.NextAllFiltered(selector).First()
+	case "closest":
+		return s.Closest(selector)
+	default:
+		return s.Next()
+	}
+}
+
+const (
+	defaultItemSelector = "tr.athing"
+	defaultMetadataRow  = "next"
+	// HN markup moved from .storylink to .titleline > a, both are tried
+	defaultTitleSelector  = ".titleline > a, .storylink"
+	defaultPointsSelector = ".score"
+	defaultAuthorSelector = ".hnuser"
+	defaultRankSelector   = ".rank"
+)
+
+// firstMatch treats selectors as a comma-separated fallback chain: each
+// candidate is tried in order and the first one with non-empty text wins
+func firstMatch(s *goquery.Selection, selectors string) *goquery.Selection {
+	for _, candidate := range strings.Split(selectors, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "" {
+			continue
+		}
+		if match := s.Find(candidate).First(); strings.TrimSpace(match.Text()) != "" {
+			return match
+		}
+	}
+	return nil
+}
+
+// normalizeAuthor trims and collapses whitespace so the same user isn't
+// split across several author values; lowercasing is opt-in per scraper
+// because HN usernames are case-sensitive
+func (p *Parser) normalizeAuthor(author string) string {
+	author = strings.Join(strings.Fields(author), " ")
+	if p.config != nil && p.config.LowercaseAuthors {
+		author = strings.ToLower(author)
+	}
+	return author
+}
+
 func (p *Parser) parseRelativeTime(ageText string) time.Time {
 	now := time.Now()
 	ageText = strings.TrimSpace(strings.ToLower(ageText))
@@ -175,17 +312,123 @@ func (p *Parser) parseComments(subtext *goquery.Selection) int {
 		}
 
 		if strings.Contains(text, "comment") {
-			text = strings.ReplaceAll(text, "\u00a0", " ")
 			text = strings.ReplaceAll(text, "&nbsp;", " ")
 			
-			var num int
-			if n, err := fmt.Sscanf(text, "%d comment", &num); err == nil && n == 1 {
-				comments = num
-			} else if n, err := fmt.Sscanf(text, "%d comments", &num); err == nil && n == 1 {
+			if num, ok := parseIntLoose(text); ok {
 				comments = num
 			}
 		}
 	})
 
 	return comments
+}
+
+// defaultTimeLayouts are tried in order on the age title. HN writes the
+// offset-less form, but a trailing Z or an offset like +00:00 turns up too.
+var defaultTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+}
+
+// parseTimestamp reads an age title such as "2024-05-01T12:00:00 1714564800":
+// an ISO time in one of the parser's layouts, optionally followed by unix
+// seconds, which are used when no layout matches. times are returned in UTC.
+func (p *Parser) parseTimestamp(raw string) (time.Time, bool) {
+	fields := strings.Fields(raw)
+	if len(fields) == 0 {
+		return time.Time{}, false
+	}
+
+	// the space-separated layout spans two fields
+	candidates := []string{fields[0]}
+	if len(fields) > 1 {
+		candidates = append(candidates, fields[0]+" "+fields[1])
+	}
+	for _, layout := range p.timeLayouts {
+		for _, candidate := range candidates {
+			if t, err := time.Parse(layout, candidate); err == nil {
+				return t.UTC(), true
+			}
+		}
+	}
+
+	for _, field := range fields {
+		if secs, err := strconv.ParseInt(field, 10, 64); err == nil && secs > 0 {
+			return time.Unix(secs, 0).UTC(), true
+		}
+	}
+
+	log.Printf("Unrecognised post timestamp %q, falling back to the relative age", raw)
+	return time.Time{}, false
+}
+
+// sanitizeUTF8 returns s unchanged when it is valid UTF-8. otherwise each
+// invalid byte is taken to be latin-1, which is what a mis-declared page
+// usually serves; bytes that would decode to control characters become
+// U+FFFD instead. the bool reports whether anything was replaced.
+func sanitizeUTF8(s string) (string, bool) {
+	if utf8.ValidString(s) {
+		return s, false
+	}
+
+	var b strings.Builder
+	b.Grow(len(s) + 8)
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			if s[i] >= 0xA0 {
+				b.WriteRune(rune(s[i]))
+			} else {
+				b.WriteRune(utf8.RuneError)
+			}
+		} else {
+			b.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	return b.String(), true
+}
+
+// parseIntLoose reads the first number in text, tolerating the comma
+// thousands separator the site uses ("1,234"). a decimal such as "1.5k"
+// isn't a count, so it is rejected rather than read as 15 or 1.
+func parseIntLoose(text string) (int, bool) {
+	runes := []rune(text)
+	start := -1
+	for i, r := range runes {
+		if unicode.IsDigit(r) {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return 0, false
+	}
+
+	isDigitAt := func(i int) bool { return i < len(runes) && unicode.IsDigit(runes[i]) }
+
+	var digits strings.Builder
+	for i := start; i < len(runes); i++ {
+		r := runes[i]
+		if unicode.IsDigit(r) {
+			digits.WriteRune(r)
+			continue
+		}
+		// a comma only separates thousands when a group of exactly three
+		// digits follows it
+		if r == ',' && isDigitAt(i+1) && isDigitAt(i+2) && isDigitAt(i+3) && !isDigitAt(i+4) {
+			continue
+		}
+		if r == '.' && isDigitAt(i+1) {
+			return 0, false
+		}
+		break
+	}
+
+	n, err := strconv.Atoi(digits.String())
+	if err != nil {
+		return 0, false
+	}
+	return n, true
 }
\ No newline at end of file
@@ -0,0 +1,79 @@
+package scraper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dzmitry-papkou/scraper/internal/config"
+)
+
+func TestParseIntLoose(t *testing.T) {
+	tests := []struct {
+		text   string
+		want   int
+		wantOK bool
+	}{
+		{"42 points", 42, true},
+		{"1,234 points", 1234, true},
+		{"1,234,567 comments", 1234567, true},
+		{"3 comments", 3, true},
+		{"12.", 12, true},
+		{"rank 7", 7, true},
+		{"1.5k points", 0, false},
+		{"1.234 points", 0, false},
+		{"1 234 points", 1, true},
+		{"12,34 points", 12, true},
+		{"1,2345 points", 1, true},
+		{"discuss", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseIntLoose(tt.text)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseIntLoose(%q) = %d, %v, want %d, %v", tt.text, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	want := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		raw    string
+		want   time.Time
+		wantOK bool
+	}{
+		{"hn title", "2024-05-01T12:00:00 1714564800", want, true},
+		{"offset-less iso", "2024-05-01T12:00:00", want, true},
+		{"rfc3339 z", "2024-05-01T12:00:00Z", want, true},
+		{"rfc3339 zero offset", "2024-05-01T12:00:00+00:00", want, true},
+		{"rfc3339 offset to utc", "2024-05-01T14:00:00+02:00", want, true},
+		{"space separated", "2024-05-01 12:00:00", want, true},
+		{"unix fallback", "01/05/2024 1714564800", want, true},
+		{"unparseable", "yesterday", time.Time{}, false},
+		{"empty", "", time.Time{}, false},
+	}
+	p := NewParser()
+	for _, tt := range tests {
+		got, ok := p.parseTimestamp(tt.raw)
+		if ok != tt.wantOK || !got.Equal(tt.want) {
+			t.Errorf("%s: parseTimestamp(%q) = %v, %v, want %v, %v", tt.name, tt.raw, got, ok, tt.want, tt.wantOK)
+		}
+		if ok && got.Location() != time.UTC {
+			t.Errorf("%s: parseTimestamp(%q) location = %v, want UTC", tt.name, tt.raw, got.Location())
+		}
+	}
+}
+
+func TestParseTimestampConfiguredLayout(t *testing.T) {
+	p := NewParserWithConfig(&config.ScraperConfig{TimeLayouts: []string{"02/01/2006 15:04"}})
+
+	got, ok := p.parseTimestamp("01/05/2024 12:00")
+	if want := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC); !ok || !got.Equal(want) {
+		t.Errorf("parseTimestamp with time_layouts = %v, %v, want %v, true", got, ok, want)
+	}
+	// the defaults still apply after the configured layouts
+	if _, ok := p.parseTimestamp("2024-05-01T12:00:00"); !ok {
+		t.Error("default layout no longer parses once time_layouts is set")
+	}
+}
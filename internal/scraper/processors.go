@@ -0,0 +1,128 @@
+package scraper
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/dzmitry-papkou/scraper/internal/config"
+	"github.com/dzmitry-papkou/scraper/internal/models"
+)
+
+// PostProcessor enriches a parsed post before it is stored. a processor that
+// returns an error is logged and skipped; the post is still inserted.
+type PostProcessor func(*models.Post) error
+
+var (
+	processorsMu sync.RWMutex
+	processors   = map[string]PostProcessor{
+		"domain": extractDomain,
+		"type":   classifyPostType,
+	}
+)
+
+func init() {
+	for name := range processors {
+		config.RegisterProcessorName(name)
+	}
+}
+
+// RegisterPostProcessor makes a processor available to scraper configs
+// under name, replacing any processor already registered with that name.
+// configs are validated against the registered names when loaded, so
+// register before loading one.
+func RegisterPostProcessor(name string, processor PostProcessor) {
+	processorsMu.Lock()
+	defer processorsMu.Unlock()
+	processors[name] = processor
+	config.RegisterProcessorName(name)
+}
+
+// configuredProcessors resolves the processor names listed in the scraper's
+// config, in order. config.Validate rejects unknown names at load, so this
+// only fails for a config built without validation.
+func configuredProcessors(scraperConfig *config.ScraperConfig) ([]PostProcessor, error) {
+	processors, err := resolveProcessors(scraperConfig.Processors)
+	if err != nil {
+		return nil, fmt.Errorf("scraper %s: %w", scraperConfig.Name, err)
+	}
+	return processors, nil
+}
+
+// resolveProcessors looks up registered processors by name, in order
+func resolveProcessors(names []string) ([]PostProcessor, error) {
+	processorsMu.RLock()
+	defer processorsMu.RUnlock()
+
+	var resolved []PostProcessor
+	for _, name := range names {
+		processor, ok := processors[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown post processor %q", name)
+		}
+		resolved = append(resolved, processor)
+	}
+	return resolved, nil
+}
+
+// runProcessors applies each processor to each post in order. a failing
+// processor is logged and the post keeps whatever the others set.
+func runProcessors(processors []PostProcessor, posts []models.Post) {
+	for i := range posts {
+		for _, process := range processors {
+			if err := process(&posts[i]); err != nil {
+				log.Printf("Post processor failed on post %d: %v", posts[i].HnID, err)
+			}
+		}
+	}
+}
+
+// extractDomain sets the linked host without a leading www.; text posts
+// link back to HN and get no domain
+func extractDomain(post *models.Post) error {
+	if post.URL == "" {
+		return nil
+	}
+	u, err := url.Parse(post.URL)
+	if err != nil {
+		return fmt.Errorf("failed to parse url %q: %w", post.URL, err)
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if host == "news.ycombinator.com" {
+		return nil
+	}
+	post.Domain = host
+	return nil
+}
+
+// title prefixes HN uses for its submission types
+var postTypePrefixes = []struct {
+	prefix   string
+	postType string
+}{
+	{"show hn:", "show"},
+	{"ask hn:", "ask"},
+	{"tell hn:", "tell"},
+	{"launch hn:", "launch"},
+}
+
+// classifyPostType sets show, ask, tell or launch from the title prefix,
+// job for hiring posts and story otherwise
+func classifyPostType(post *models.Post) error {
+	title := strings.ToLower(strings.TrimSpace(post.Title))
+	for _, p := range postTypePrefixes {
+		if strings.HasPrefix(title, p.prefix) {
+			post.PostType = p.postType
+			return nil
+		}
+	}
+	if strings.Contains(title, " is hiring") {
+		post.PostType = "job"
+		return nil
+	}
+	post.PostType = "story"
+	return nil
+}
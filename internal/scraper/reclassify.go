@@ -0,0 +1,64 @@
+package scraper
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/dzmitry-papkou/scraper/internal/database"
+)
+
+// DefaultReclassifyProcessors are the processors whose output has columns
+var DefaultReclassifyProcessors = []string{"domain", "type"}
+
+const reclassifyBatchSize = 500
+
+type ReclassifyResult struct {
+	Checked int
+	Updated int
+	// posts where at least one processor failed; they keep what the
+	// others derived
+	Failed int
+}
+
+// Reclassify re-runs the named post processors over every stored post and
+// writes back the derived domain and post_type, one transaction per batch,
+// so rows stored before a processor was configured don't need re-scraping
+func Reclassify(repo *database.Repository, processorNames []string) (*ReclassifyResult, error) {
+	processors, err := resolveProcessors(processorNames)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ReclassifyResult{}
+	lastID := 0
+	for {
+		posts, err := repo.GetPostsAfterID(lastID, reclassifyBatchSize)
+		if err != nil {
+			return result, fmt.Errorf("failed to load posts after id %d: %w", lastID, err)
+		}
+		if len(posts) == 0 {
+			return result, nil
+		}
+
+		for i := range posts {
+			failed := false
+			for _, process := range processors {
+				if err := process(&posts[i]); err != nil {
+					log.Printf("Post processor failed on post %d: %v", posts[i].HnID, err)
+					failed = true
+				}
+			}
+			if failed {
+				result.Failed++
+			}
+		}
+
+		updated, err := repo.UpdateClassifications(posts)
+		if err != nil {
+			return result, err
+		}
+		result.Checked += len(posts)
+		result.Updated += updated
+		lastID = posts[len(posts)-1].ID
+	}
+}
@@ -29,6 +29,11 @@ func (s *Scheduler) Start(interval time.Duration) {
 	if s.isActive {
 		return
 	}
+	// time.NewTicker panics on a non-positive interval
+	if interval <= 0 {
+		log.Printf("Not starting scheduler: invalid interval %s", interval)
+		return
+	}
 
 	s.ticker = time.NewTicker(interval)
 	s.isActive = true
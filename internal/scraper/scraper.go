@@ -1,12 +1,14 @@
 package scraper
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"sync"
 	"time"
 
-	"github.com/PuerkitoBio/goquery"
 	"github.com/dzmitry-papkou/scraper/internal/config"
 	"github.com/dzmitry-papkou/scraper/internal/database"
 	"github.com/dzmitry-papkou/scraper/internal/models"
@@ -16,9 +18,15 @@ type Scraper struct {
 	repo   *database.Repository
 	config *config.ScraperConfig
 	parser *Parser
+	client *http.Client
+
+	processors []PostProcessor
+
+	alertsMu sync.Mutex
+	alerts   []PointsAlert
 }
 
-func New(repo *database.Repository) *Scraper {
+func New(repo *database.Repository) (*Scraper, error) {
 	scraperConfig, _ := config.GetScraper("hackernews")
 	if scraperConfig == nil {
 		scraperConfig = &config.ScraperConfig{
@@ -28,19 +36,22 @@ func New(repo *database.Repository) *Scraper {
 		}
 	}
 
-	return &Scraper{
-		repo:   repo,
-		config: scraperConfig,
-		parser: NewParser(),
-	}
+	return NewWithConfig(repo, scraperConfig)
 }
 
-func NewWithConfig(repo *database.Repository, scraperConfig *config.ScraperConfig) *Scraper {
-	return &Scraper{
-		repo:   repo,
-		config: scraperConfig,
-		parser: NewParser(),
+func NewWithConfig(repo *database.Repository, scraperConfig *config.ScraperConfig) (*Scraper, error) {
+	processors, err := configuredProcessors(scraperConfig)
+	if err != nil {
+		return nil, err
 	}
+
+	return &Scraper{
+		repo:       routeRepo(repo, scraperConfig),
+		config:     scraperConfig,
+		parser:     NewParserWithConfig(scraperConfig),
+		client:     http.DefaultClient,
+		processors: processors,
+	}, nil
 }
 
 func NewGenericScraper(repo *database.Repository, scraperName string) (*Scraper, error) {
@@ -49,14 +60,37 @@ func NewGenericScraper(repo *database.Repository, scraperName string) (*Scraper,
 		return nil, fmt.Errorf("scraper %s not found in config: %w", scraperName, err)
 	}
 
-	return &Scraper{
-		repo:   repo,
-		config: scraperConfig,
-		parser: NewParser(),
-	}, nil
+	return NewWithConfig(repo, scraperConfig)
+}
+
+// routeRepo points repo at the scraper's dedicated table when it has one.
+// config.Validate rejects invalid and reserved names at load, so a failure
+// here means the config was built without validation; fall back to the
+// shared table rather than not scraping.
+func routeRepo(repo *database.Repository, scraperConfig *config.ScraperConfig) *database.Repository {
+	if repo == nil || scraperConfig.Table == "" {
+		return repo
+	}
+	routed, err := repo.ForTable(scraperConfig.Table)
+	if err != nil {
+		log.Printf("Warning: scraper %s: %v, using the shared posts table", scraperConfig.Name, err)
+		return repo
+	}
+	return routed
 }
 
 func (s *Scraper) ScrapeOnce() (int, error) {
+	return s.ScrapeOnceContext(context.Background())
+}
+
+// ScrapeOnceContext is ScrapeOnce with a context; cancelling it or letting
+// its deadline pass aborts the page request, or stops storing posts if it
+// comes after the fetch
+func (s *Scraper) ScrapeOnceContext(ctx context.Context) (int, error) {
+	if s.repo == nil {
+		return 0, fmt.Errorf("scraper %s has no repository, use Fetch instead", s.config.Name)
+	}
+
 	startTime := time.Now()
 	log.Printf("Scraping %s from %s", s.config.Name, s.config.URL)
 
@@ -65,31 +99,44 @@ func (s *Scraper) ScrapeOnce() (int, error) {
 		return 0, fmt.Errorf("failed to create job: %w", err)
 	}
 
-	posts, err := s.fetchAndParse()
+	posts, err := s.fetchAndParse(ctx)
 	if err != nil {
-		s.repo.UpdateScrapingJob(jobID, "failed", 0, err.Error())
+		s.repo.UpdateScrapingJob(jobID, models.JobStatusFailed, 0, err.Error())
 		return 0, fmt.Errorf("failed to fetch/parse: %w", err)
 	}
 
-	saved := 0
-	for _, post := range posts {
-		if post.PostTime.IsZero() || post.PostTime.Year() < 2000 {
-			log.Printf("WARNING: Post %d has invalid time %v, using current time", post.HnID, post.PostTime)
-			post.PostTime = time.Now()
+	// snapshots are queued while storing and written once the run ends,
+	// however it ends
+	defer func() {
+		if err := FlushHistory(); err != nil {
+			log.Printf("Scraper %s: %v", s.config.Name, err)
 		}
+	}()
 
+	saved, failed := 0, 0
+	for _, post := range posts {
+		// a run cancelled mid-way keeps what it stored and stops there
+		if err := ctx.Err(); err != nil {
+			s.repo.UpdateScrapingJob(jobID, models.JobStatusPartial, saved,
+				fmt.Sprintf("cancelled after %d of %d posts: %v", saved, len(posts), err))
+			return saved, err
+		}
 		if err := s.repo.InsertPost(&post); err != nil {
 			log.Printf("Failed to insert post %d: %v", post.HnID, err)
+			failed++
 			continue
 		}
 		saved++
-
-		if post.ID > 0 {
-			s.repo.InsertPostHistory(post.ID, post.Points, post.CommentsCount)
-		}
+		s.checkAlert(&post)
+		recordStoredPost(s.repo, &post)
 	}
 
-	s.repo.UpdateScrapingJob(jobID, "completed", saved, "")
+	if failed > 0 {
+		s.repo.UpdateScrapingJob(jobID, models.JobStatusPartial, saved,
+			fmt.Sprintf("%d of %d inserts failed", failed, len(posts)))
+	} else {
+		s.repo.UpdateScrapingJob(jobID, models.JobStatusCompleted, saved, "")
+	}
 
 	duration := time.Since(startTime)
 	log.Printf("Scraped %d posts from %s in %.2f seconds", saved, s.config.Name, duration.Seconds())
@@ -97,24 +144,133 @@ func (s *Scraper) ScrapeOnce() (int, error) {
 	return saved, nil
 }
 
-func (s *Scraper) fetchAndParse() ([]models.Post, error) {
-	resp, err := http.Get(s.config.URL)
+// Fetch downloads and parses the configured page without storing anything,
+// so it works on a scraper built with a nil repository
+func (s *Scraper) Fetch() ([]models.Post, error) {
+	posts, err := s.fetchAndParse(context.Background())
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch page: %w", err)
+		return nil, fmt.Errorf("failed to fetch/parse: %w", err)
 	}
-	defer resp.Body.Close()
+	return posts, nil
+}
 
+func (s *Scraper) fetchAndParse(ctx context.Context) ([]models.Post, error) {
+	doc, err := fetchDocument(ctx, s.client, s.config, s.config.URL)
+	if err != nil {
+		return nil, err
+	}
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	posts, err := s.parser.ParseDocument(doc)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch page: %w", err)
+		return nil, err
+	}
+
+	for i := range posts {
+		if !posts[i].Partial && (posts[i].PostTime.IsZero() || posts[i].PostTime.Year() < 2000) {
+			log.Printf("WARNING: Post %d has invalid time %v, using current time", posts[i].HnID, posts[i].PostTime)
+			posts[i].PostTime = time.Now()
+		}
 	}
 
-	if s.config.Name == "hackernews" {
-		return s.parser.ParseDocument(doc)
+	runProcessors(s.processors, posts)
+	return posts, nil
+}
+
+// RefreshPosts revisits recent posts that haven't been updated in the last
+// hour and updates their points/comments from the item page
+func (s *Scraper) RefreshPosts(limit int) (refreshed int, checked int, err error) {
+	posts, err := s.repo.GetRecentPostsNotUpdatedFor(time.Hour, limit)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to load posts to refresh: %w", err)
 	}
+	defer func() {
+		if err := FlushHistory(); err != nil {
+			log.Printf("Scraper %s: %v", s.config.Name, err)
+		}
+	}()
+
+	for i, post := range posts {
+		if i > 0 {
+			time.Sleep(1 * time.Second)
+		}
+		checked++
+
+		fresh, err := s.fetchItem(post.HnID)
+		if err != nil {
+			log.Printf("Failed to refresh post %d: %v", post.HnID, err)
+			continue
+		}
+		if fresh.Partial {
+			log.Printf("Post %d item page has no score, keeping the stored one", post.HnID)
+			continue
+		}
+
+		// item pages can be served from a stale cache, so never lower a score
+		if err := s.repo.UpdatePostWithStrategy(fresh, database.UpdateIfHigher); err != nil {
+			log.Printf("Failed to update post %d: %v", post.HnID, err)
+			continue
+		}
+		recordStoredPost(s.repo, fresh)
+		refreshed++
+	}
+
+	return refreshed, checked, nil
+}
+
+func (s *Scraper) fetchItem(hnID int) (*models.Post, error) {
+	doc, err := fetchDocument(context.Background(), s.client, s.config, s.itemURL(hnID))
+	if err != nil {
+		return nil, err
+	}
+
+	// item pages also contain comment rows, so pick the submission by id
+	posts, err := s.parser.ParseDocument(doc)
+	if err != nil {
+		return nil, err
+	}
+	for i := range posts {
+		if posts[i].HnID == hnID {
+			return &posts[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("post %d not found on item page", hnID)
+}
+
+func (s *Scraper) itemURL(hnID int) string {
+	base := "https://news.ycombinator.com"
+	if u, err := url.Parse(s.config.URL); err == nil && u.Host != "" {
+		base = u.Scheme + "://" + u.Host
+	}
+	return fmt.Sprintf("%s/item?id=%d", base, hnID)
+}
+
+func (s *Scraper) checkAlert(post *models.Post) {
+	if alert, ok := checkPointsAlert(s.config.Name, s.config.AlertPoints, post); ok {
+		s.alertsMu.Lock()
+		s.alerts = append(s.alerts, alert)
+		s.alertsMu.Unlock()
+	}
+}
+
+// TakeAlerts returns the points alerts raised since the last call
+func (s *Scraper) TakeAlerts() []PointsAlert {
+	s.alertsMu.Lock()
+	defer s.alertsMu.Unlock()
+	alerts := s.alerts
+	s.alerts = nil
+	return alerts
+}
+
+// AddPostProcessor appends an enrichment step after the configured ones
+func (s *Scraper) AddPostProcessor(processor PostProcessor) {
+	s.processors = append(s.processors, processor)
+}
 
-	return s.parser.ParseDocument(doc)
+// SetHTTPClient replaces the client used for page requests, e.g. to point
+// the scraper at a local server
+func (s *Scraper) SetHTTPClient(client *http.Client) {
+	s.client = client
 }
 
 func (s *Scraper) GetConfig() *config.ScraperConfig {
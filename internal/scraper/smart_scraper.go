@@ -1,13 +1,14 @@
 package scraper
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
-	"github.com/PuerkitoBio/goquery"
 	"github.com/dzmitry-papkou/scraper/internal/config"
 	"github.com/dzmitry-papkou/scraper/internal/database"
 	"github.com/dzmitry-papkou/scraper/internal/models"
@@ -17,9 +18,19 @@ type SmartScraper struct {
 	repo            *database.Repository
 	config          *config.ScraperConfig
 	parser          *Parser
+	client          *http.Client
 	mode            ScrapingMode
 	maxPages        int
 	stopOnDuplicate bool
+	upsertStrategy  database.UpsertStrategy
+	maxDuration     time.Duration
+	processors      []PostProcessor
+	// full archive only: where progress is recorded after each page, and
+	// whether an existing checkpoint is continued from
+	checkpointPath  string
+	resume          bool
+	// historical front only: the inclusive range of days to scrape
+	fromDay, toDay  time.Time
 }
 
 type ScrapingMode string
@@ -29,20 +40,90 @@ const (
 	ModeUntilExisting ScrapingMode = "until_existing"
 	ModeFullArchive   ScrapingMode = "full"
 	ModeSinceLast     ScrapingMode = "since_last"
+	ModeCatchUp       ScrapingMode = "catchup"
+	// walks HN's front?day= pages, one ranked front page per day
+	ModeHistoricalFront ScrapingMode = "historical_front"
+	// updates scores of stored posts on page 1 without inserting new ones
+	ModeRefreshFront ScrapingMode = "refresh_front"
 )
 
-func NewSmartScraper(repo *database.Repository, scraperConfig *config.ScraperConfig, mode ScrapingMode, maxPages int) *SmartScraper {
+func NewSmartScraper(repo *database.Repository, scraperConfig *config.ScraperConfig, mode ScrapingMode, maxPages int) (*SmartScraper, error) {
+	processors, err := configuredProcessors(scraperConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// archive pages carry stale scores, so existing posts are left alone
+	strategy := database.UpdateScores
+	if mode == ModeFullArchive {
+		strategy = database.InsertOnly
+	}
+
+	// the ceiling applies to every mode, however many pages were asked for
+	if limit := config.Get().App.MaxPagesLimit; limit > 0 && maxPages > limit {
+		log.Printf("Scraper %s: %d pages requested, limiting to max_pages_limit %d", scraperConfig.Name, maxPages, limit)
+		maxPages = limit
+	}
+
 	return &SmartScraper{
-		repo:            repo,
+		repo:            routeRepo(repo, scraperConfig),
 		config:          scraperConfig,
-		parser:          NewParser(),
+		parser:          NewParserWithConfig(scraperConfig),
+		client:          http.DefaultClient,
 		mode:            mode,
 		maxPages:        maxPages,
-		stopOnDuplicate: mode == ModeUntilExisting || mode == ModeSinceLast,
-	}
+		stopOnDuplicate: mode == ModeUntilExisting || mode == ModeSinceLast || mode == ModeCatchUp,
+		upsertStrategy:  strategy,
+		maxDuration:     scraperConfig.MaxDuration,
+		processors:      processors,
+	}, nil
+}
+
+// SetUpsertStrategy overrides how posts that are already stored are treated
+func (s *SmartScraper) SetUpsertStrategy(strategy database.UpsertStrategy) {
+	s.upsertStrategy = strategy
+}
+
+// AddPostProcessor appends an enrichment step after the configured ones
+func (s *SmartScraper) AddPostProcessor(processor PostProcessor) {
+	s.processors = append(s.processors, processor)
+}
+
+// SetHTTPClient replaces the client used for page requests
+func (s *SmartScraper) SetHTTPClient(client *http.Client) {
+	s.client = client
+}
+
+// SetCheckpoint makes a full archive scrape record its progress in path
+// after every page. with resume set, a checkpoint already at path is
+// continued from instead of starting over at page 1.
+func (s *SmartScraper) SetCheckpoint(path string, resume bool) {
+	s.checkpointPath = path
+	s.resume = resume
+}
+
+// SetDateRange sets the days a historical front scrape covers, inclusive
+func (s *SmartScraper) SetDateRange(from, to time.Time) {
+	s.fromDay = from
+	s.toDay = to
+}
+
+// SetMaxDuration overrides the configured wall-clock budget for the page loop
+func (s *SmartScraper) SetMaxDuration(d time.Duration) {
+	s.maxDuration = d
 }
 
 func (s *SmartScraper) ScrapeWithStrategy() (*ScrapingResult, error) {
+	// a second process scraping the same source would duplicate the work
+	// and race on upserts, so it fails fast instead
+	if !config.Get().App.DisableScrapeLock {
+		lock, err := database.AcquireScrapeLock(s.config.Name)
+		if err != nil {
+			return nil, err
+		}
+		defer lock.Release()
+	}
+
 	result := &ScrapingResult{
 		StartTime: time.Now(),
 		Mode:      s.mode,
@@ -65,20 +146,75 @@ func (s *SmartScraper) ScrapeWithStrategy() (*ScrapingResult, error) {
 		err = s.scrapeSinceLast(result, lastKnownID)
 	case ModeFullArchive:
 		err = s.scrapeFullArchive(result)
+	case ModeCatchUp:
+		err = s.scrapeCatchUp(result, lastKnownID)
+	case ModeHistoricalFront:
+		err = s.scrapeHistoricalFront(result)
+	case ModeRefreshFront:
+		err = s.scrapeRefreshFront(result)
 	default:
 		err = s.scrapeLatestPage(result)
 	}
 
+	s.retryFailedInserts(result)
+	// every mode queues its snapshots, so this covers them all
+	if flushErr := FlushHistory(); flushErr != nil {
+		log.Printf("Scraper %s: %v", s.config.Name, flushErr)
+	}
+	s.checkParseRegression(result)
+
 	result.EndTime = time.Now()
 	result.Duration = result.EndTime.Sub(result.StartTime)
 
-	s.saveScrapingResult(result)
+	s.saveScrapingResult(result, err)
 
 	return result, err
 }
 
+// scrapeRefreshFront is the inverse of scrape-new: posts on the first page
+// that are already stored get their scores updated, and the rest are left
+// for a later scrape-new so this stays cheap enough to run every minute
+func (s *SmartScraper) scrapeRefreshFront(result *ScrapingResult) error {
+	posts, err := s.scrapePage(s.config.URL, 1, result)
+	if err != nil {
+		return err
+	}
+	result.PagesScraped = 1
+
+	start := time.Now()
+	defer func() { result.InsertTime += time.Since(start) }()
+
+	existing, err := s.repo.FilterExistingIDs(postIDs(posts))
+	if err != nil {
+		return fmt.Errorf("failed to check existing posts: %w", err)
+	}
+
+	skipped := 0
+	for _, post := range posts {
+		if post.HnID > result.HighestIDSeen {
+			result.HighestIDSeen = post.HnID
+		}
+		if !existing[post.HnID] || post.Partial {
+			skipped++
+			continue
+		}
+		if err := s.updatePost(&post); err != nil {
+			log.Printf("Failed to update post %d: %v", post.HnID, err)
+			result.Errors = append(result.Errors, fmt.Sprintf("Post %d: %v", post.HnID, err))
+			continue
+		}
+		result.UpdatedPosts++
+		s.checkAlert(&post, result)
+	}
+
+	if skipped > 0 {
+		result.StopReason = fmt.Sprintf("%d posts not stored yet were skipped, scrape-new stores them", skipped)
+	}
+	return nil
+}
+
 func (s *SmartScraper) scrapeLatestPage(result *ScrapingResult) error {
-	posts, err := s.scrapePage(s.config.URL, 1)
+	posts, err := s.scrapePage(s.config.URL, 1, result)
 	if err != nil {
 		return err
 	}
@@ -90,83 +226,189 @@ func (s *SmartScraper) scrapeLatestPage(result *ScrapingResult) error {
 	return nil
 }
 
+// HN occasionally lists a resurfaced old post above newer ones, so a single
+// known id isn't proof we've caught up
+const defaultKnownIDTolerance = 3
+
+func (s *SmartScraper) knownIDTolerance() int {
+	if s.config.StopAfterKnown > 0 {
+		return s.config.StopAfterKnown
+	}
+	return defaultKnownIDTolerance
+}
+
 func (s *SmartScraper) scrapeSinceLast(result *ScrapingResult, lastKnownID int) error {
+	// a failed page ends the run with what was found so far
+	if _, err := s.scrapeSinceLastPages(result, lastKnownID, 1, s.maxPages, make(map[int]bool)); err != nil {
+		result.Errors = append(result.Errors, err.Error())
+	}
+	return nil
+}
+
+// scrapeSinceLastPages walks pages firstPage..lastPage collecting posts newer
+// than lastKnownID and reports whether it reached already-known posts. seen
+// holds the ids already collected; catch-up shares it across its windows,
+// since posts shift from one window into the next while paging.
+func (s *SmartScraper) scrapeSinceLastPages(result *ScrapingResult, lastKnownID, firstPage, lastPage int, seen map[int]bool) (caughtUp bool, pageErr error) {
 	allNewPosts := []models.Post{}
 	foundLastKnown := false
+	consecutiveKnown := 0
+	tolerance := s.knownIDTolerance()
 
-	for page := 1; page <= s.maxPages && !foundLastKnown; page++ {
+	for page := firstPage; page <= lastPage && !foundLastKnown; page++ {
 		url := s.buildPageURL(page)
-		posts, err := s.scrapePage(url, page)
+		posts, err := s.scrapePage(url, page, result)
 		if err != nil {
 			log.Printf("Error scraping page %d: %v", page, err)
+			pageErr = fmt.Errorf("page %d: %w", page, err)
 			break
 		}
 
 		for _, post := range posts {
 			if post.HnID <= lastKnownID {
-				foundLastKnown = true
-				break
+				consecutiveKnown++
+				if consecutiveKnown >= tolerance {
+					foundLastKnown = true
+					break
+				}
+				continue
+			}
+			consecutiveKnown = 0
+
+			if !seen[post.HnID] {
+				seen[post.HnID] = true
+				allNewPosts = append(allNewPosts, post)
 			}
-			allNewPosts = append(allNewPosts, post)
 		}
 
-		result.PagesScraped = page
+		result.PagesScraped++
 		time.Sleep(1 * time.Second)
 	}
 
+	insertStart := time.Now()
 	for _, post := range allNewPosts {
-		if err := s.repo.InsertPost(&post); err == nil {
+		inserted, err := s.insertPost(&post)
+		if err != nil {
+			result.insertFailed(post, err)
+			continue
+		}
+		if inserted {
 			result.PostsScraped++
 			result.NewPosts++
 		}
+		s.checkAlert(&post, result)
 	}
+	result.InsertTime += time.Since(insertStart)
 
 	log.Printf("Found %d new posts since ID %d", len(allNewPosts), lastKnownID)
-	return nil
+	return foundLastKnown, pageErr
 }
 
-func (s *SmartScraper) scrapePage(url string, pageNum int) ([]models.Post, error) {
-	log.Printf("Scraping page %d: %s", pageNum, url)
+// catch-up walks this many pages per run and bounds itself by time even
+// when no max_duration is configured
+const (
+	catchUpRunPages       = 10
+	defaultCatchUpTimeout = 10 * time.Minute
+)
 
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch page: %w", err)
+// scrapeCatchUp repeats since-last runs over successive page windows,
+// keeping the starting last-known id fixed, until a run reaches known posts
+// or adds nothing new. maxPages bounds the total across all runs.
+func (s *SmartScraper) scrapeCatchUp(result *ScrapingResult, lastKnownID int) error {
+	timeout := s.maxDuration
+	if timeout <= 0 {
+		timeout = defaultCatchUpTimeout
 	}
-	defer resp.Body.Close()
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	seen := make(map[int]bool)
+	for run, firstPage := 1, 1; ; run, firstPage = run+1, firstPage+catchUpRunPages {
+		if firstPage > s.maxPages {
+			result.StopReason = "stopped: page limit"
+			break
+		}
+		if time.Since(result.StartTime) >= timeout {
+			result.StopReason = "stopped: time limit"
+			break
+		}
+
+		lastPage := firstPage + catchUpRunPages - 1
+		if lastPage > s.maxPages {
+			lastPage = s.maxPages
+		}
+
+		before := result.NewPosts
+		caughtUp, err := s.scrapeSinceLastPages(result, lastKnownID, firstPage, lastPage, seen)
+		log.Printf("Catch-up run %d (pages %d-%d): %d new posts", run, firstPage, lastPage, result.NewPosts-before)
+
+		if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+			return err
+		}
+		if caughtUp || result.NewPosts == before {
+			break
+		}
+	}
+
+	return nil
+}
+
+func (s *SmartScraper) scrapePage(url string, pageNum int, result *ScrapingResult) ([]models.Post, error) {
+	log.Printf("Scraping page %d: %s", pageNum, url)
+
+	fetchStart := time.Now()
+	doc, err := fetchDocument(context.Background(), s.client, s.config, url)
+	result.FetchTime += time.Since(fetchStart)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse page: %w", err)
+		return nil, err
 	}
 
+	parseStart := time.Now()
 	posts, err := s.parser.ParseDocument(doc)
+	result.ParseTime += time.Since(parseStart)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse page: %w", err)
 	}
+	result.recordPageCount(pageNum, len(posts))
 
 	for i := range posts {
-		if posts[i].PostTime.IsZero() || posts[i].PostTime.Year() < 2000 {
+		if !posts[i].Partial && (posts[i].PostTime.IsZero() || posts[i].PostTime.Year() < 2000) {
 			log.Printf("Warning: Post %d has invalid time, using current time", posts[i].HnID)
 			posts[i].PostTime = time.Now()
 		}
 	}
 
+	runProcessors(s.processors, posts)
 	return posts, nil
 }
 
 func (s *SmartScraper) savePosts(posts []models.Post, result *ScrapingResult) int {
+	start := time.Now()
+	defer func() { result.InsertTime += time.Since(start) }()
+
 	saved := 0
+	existing, err := s.repo.FilterExistingIDs(postIDs(posts))
+	if err != nil {
+		log.Printf("Warning: Could not check existing posts: %v", err)
+	}
+
 	for _, post := range posts {
-		exists, _ := s.repo.PostExists(post.HnID)
-		
-		if exists {
-			if err := s.repo.UpdatePost(&post); err == nil {
-				result.UpdatedPosts++
+		if existing[post.HnID] {
+			if s.upsertStrategy != database.InsertOnly && !post.Partial {
+				if err := s.updatePost(&post); err == nil {
+					result.UpdatedPosts++
+					s.checkAlert(&post, result)
+				}
 			}
 		} else {
-			if err := s.repo.InsertPost(&post); err == nil {
-				saved++
-				result.NewPosts++
+			inserted, err := s.insertPost(&post)
+			if err != nil {
+				result.insertFailed(post, err)
+			} else {
+				if inserted {
+					saved++
+					result.NewPosts++
+				}
+				s.checkAlert(&post, result)
 			}
 		}
 
@@ -177,6 +419,39 @@ func (s *SmartScraper) savePosts(posts []models.Post, result *ScrapingResult) in
 	return saved
 }
 
+func (s *SmartScraper) checkAlert(post *models.Post, result *ScrapingResult) {
+	if alert, ok := checkPointsAlert(s.config.Name, s.config.AlertPoints, post); ok {
+		result.Alerts = append(result.Alerts, alert)
+	}
+}
+
+// insertPost and updatePost store post and queue a snapshot of the scores
+// that ended up in the row
+func (s *SmartScraper) insertPost(post *models.Post) (bool, error) {
+	inserted, err := s.repo.UpsertPost(post, s.upsertStrategy)
+	if err != nil {
+		return false, err
+	}
+	recordStoredPost(s.repo, post)
+	return inserted, nil
+}
+
+func (s *SmartScraper) updatePost(post *models.Post) error {
+	if err := s.repo.UpdatePostWithStrategy(post, s.upsertStrategy); err != nil {
+		return err
+	}
+	recordStoredPost(s.repo, post)
+	return nil
+}
+
+func postIDs(posts []models.Post) []int {
+	ids := make([]int, len(posts))
+	for i, post := range posts {
+		ids[i] = post.HnID
+	}
+	return ids
+}
+
 type ScrapingResult struct {
 	StartTime      time.Time
 	EndTime        time.Time
@@ -190,10 +465,103 @@ type ScrapingResult struct {
 	LastKnownID    int
 	HighestIDSeen  int
 	Errors         []string
+	StopReason     string
+	// page a full archive scrape continued after, zero for a fresh run
+	ResumedAfterPage int
+	// set when far fewer posts per page were parsed than in recent runs
+	ParseWarning   string
+	PostsPerPage   []int
+	// posts that crossed alert_points during this run
+	Alerts         []PointsAlert
+
+	// time spent in each stage, summed over pages
+	FetchTime  time.Duration
+	ParseTime  time.Duration
+	InsertTime time.Duration
+
+	// posts whose insert failed, retried once after the page loop
+	failed []models.Post
+}
+
+// recordPageCount keeps the parsed count for the parse regression check. an
+// empty page past the first is just the end of the listing, not a regression.
+func (r *ScrapingResult) recordPageCount(page, posts int) {
+	if posts > 0 || page == 1 {
+		r.PostsPerPage = append(r.PostsPerPage, posts)
+	}
+}
+
+func (r *ScrapingResult) insertFailed(post models.Post, err error) {
+	log.Printf("Failed to insert post %d, will retry: %v", post.HnID, err)
+	r.failed = append(r.failed, post)
+}
+
+// retryFailedInserts gives posts that failed mid-scrape (a deadlock or a
+// dropped connection) one more attempt and records the ones that still fail
+func (s *SmartScraper) retryFailedInserts(result *ScrapingResult) {
+	if len(result.failed) == 0 {
+		return
+	}
+
+	log.Printf("Retrying %d failed inserts", len(result.failed))
+	start := time.Now()
+	for _, post := range result.failed {
+		inserted, err := s.insertPost(&post)
+		if err != nil {
+			log.Printf("Insert of post %d failed again: %v", post.HnID, err)
+			result.Errors = append(result.Errors, fmt.Sprintf("Post %d: %v", post.HnID, err))
+			continue
+		}
+		if inserted {
+			result.PostsScraped++
+			result.NewPosts++
+		}
+		s.checkAlert(&post, result)
+	}
+	result.InsertTime += time.Since(start)
+	result.failed = nil
 }
 
-func (s *SmartScraper) saveScrapingResult(result *ScrapingResult) {
-	s.repo.CreateDetailedScrapingJob(result)
+func (r *ScrapingResult) jobDetails(scraper string) *models.ScrapingJobDetails {
+	return &models.ScrapingJobDetails{
+		Scraper:       scraper,
+		Mode:          string(r.Mode),
+		NewPosts:      r.NewPosts,
+		UpdatedPosts:  r.UpdatedPosts,
+		DeletedPosts:  r.DeletedPosts,
+		PagesScraped:  r.PagesScraped,
+		LastKnownID:   r.LastKnownID,
+		HighestIDSeen: r.HighestIDSeen,
+		Errors:        r.Errors,
+		StopReason:    r.StopReason,
+		PostsPerPage:  r.PostsPerPage,
+	}
+}
+
+func (s *SmartScraper) saveScrapingResult(result *ScrapingResult, scrapeErr error) {
+	job := &models.ScrapingJob{
+		StartedAt:    result.StartTime,
+		CompletedAt:  &result.EndTime,
+		Status:       models.JobStatusCompleted,
+		PostsScraped: result.PostsScraped,
+		Details:      result.jobDetails(s.config.Name),
+	}
+	switch {
+	case scrapeErr != nil:
+		errMsg := scrapeErr.Error()
+		job.Status = models.JobStatusFailed
+		job.ErrorMessage = &errMsg
+	case len(result.Errors) > 0:
+		// page fetches that cut the run short and inserts that failed twice
+		// both land in Errors
+		errMsg := fmt.Sprintf("%d error(s), first: %s", len(result.Errors), result.Errors[0])
+		job.Status = models.JobStatusPartial
+		job.ErrorMessage = &errMsg
+	}
+
+	if err := s.repo.CreateDetailedScrapingJob(job); err != nil {
+		log.Printf("Warning: Could not save scraping job: %v", err)
+	}
 }
 
 func (s *SmartScraper) buildPageURL(page int) string {
@@ -212,60 +580,200 @@ func (s *SmartScraper) buildPageURL(page int) string {
 
 
 func (s *SmartScraper) scrapeFullArchive(result *ScrapingResult) error {
-	for page := 1; page <= s.maxPages; page++ {
+	checkpoint, err := s.startCheckpoint(result)
+	if err != nil {
+		return err
+	}
+
+	// the checkpoint is only removed once the archive has been walked to
+	// its end; errors, the time limit and the page cap leave it for the
+	// next --resume. maxPages counts the pages of this run, so a resumed
+	// run gets a full allowance from where the last one stopped.
+	finished := false
+	firstPage := checkpoint.LastPage + 1
+	lastPage := checkpoint.LastPage + s.maxPages
+	for page := firstPage; page <= lastPage; page++ {
+		if s.maxDuration > 0 && time.Since(result.StartTime) >= s.maxDuration {
+			log.Printf("Time limit of %s reached before page %d, stopping", s.maxDuration, page)
+			result.StopReason = "stopped: time limit"
+			break
+		}
+
 		url := s.buildPageURL(page)
 		log.Printf("Scraping page %d: %s", page, url)
 		
-		resp, err := http.Get(url)
+		fetchStart := time.Now()
+		doc, err := fetchDocument(context.Background(), s.client, s.config, url)
+		result.FetchTime += time.Since(fetchStart)
 		if err != nil {
 			log.Printf("Error fetching page %d: %v", page, err)
 			result.Errors = append(result.Errors, fmt.Sprintf("Page %d: %v", page, err))
 			break
 		}
-		defer resp.Body.Close()
-		
-		doc, err := goquery.NewDocumentFromReader(resp.Body)
-		if err != nil {
-			log.Printf("Error parsing page %d: %v", page, err)
-			result.Errors = append(result.Errors, fmt.Sprintf("Page %d parse: %v", page, err))
-			break
-		}
 		
+		parseStart := time.Now()
 		posts, err := s.parser.ParseDocument(doc)
+		result.ParseTime += time.Since(parseStart)
 		if err != nil {
 			log.Printf("Error parsing posts on page %d: %v", page, err)
 			result.Errors = append(result.Errors, fmt.Sprintf("Page %d posts: %v", page, err))
 			continue
 		}
+		result.recordPageCount(page, len(posts))
+		runProcessors(s.processors, posts)
 		
 		if len(posts) == 0 {
 			log.Printf("No posts found on page %d, stopping", page)
+			finished = true
 			break
 		}
 		
 		saved := s.savePosts(posts, result)
 		result.PostsScraped += saved
 		result.PagesScraped = page
+		s.advanceCheckpoint(checkpoint, page, len(posts), result)
 		
 		if s.stopOnDuplicate && saved == 0 {
 			log.Printf("No new posts saved on page %d (stop on duplicate enabled), stopping", page)
+			finished = true
 			break
 		}
 		
+		if page == lastPage {
+			log.Printf("Page limit of %d reached at page %d, stopping", s.maxPages, page)
+			result.StopReason = "stopped: page limit"
+			break
+		}
 		time.Sleep(2 * time.Second)
 	}
+
+	if finished && s.checkpointPath != "" {
+		removeCheckpoint(s.checkpointPath)
+	}
 	
 	return nil
 }
 
+// startCheckpoint returns the progress to continue from: the saved
+// checkpoint when resuming, otherwise an empty one starting at page 1
+func (s *SmartScraper) startCheckpoint(result *ScrapingResult) (*ArchiveCheckpoint, error) {
+	fresh := &ArchiveCheckpoint{Scraper: s.config.Name}
+	if s.checkpointPath == "" || !s.resume {
+		return fresh, nil
+	}
+
+	checkpoint, err := LoadCheckpoint(s.checkpointPath)
+	if err != nil {
+		return nil, err
+	}
+	if checkpoint == nil {
+		log.Printf("No checkpoint at %s, starting from page 1", s.checkpointPath)
+		return fresh, nil
+	}
+	if checkpoint.Scraper != s.config.Name {
+		return nil, fmt.Errorf("checkpoint %s belongs to scraper %s, not %s",
+			s.checkpointPath, checkpoint.Scraper, s.config.Name)
+	}
+
+	log.Printf("Resuming archive after page %d (%d posts seen, highest id %d)",
+		checkpoint.LastPage, checkpoint.SeenIDs, checkpoint.HighestID)
+	result.ResumedAfterPage = checkpoint.LastPage
+	return checkpoint, nil
+}
+
+// advanceCheckpoint records a finished page. a failed write is only logged:
+// losing the checkpoint costs a re-scrape, not data.
+func (s *SmartScraper) advanceCheckpoint(checkpoint *ArchiveCheckpoint, page, posts int, result *ScrapingResult) {
+	if s.checkpointPath == "" {
+		return
+	}
+	checkpoint.LastPage = page
+	checkpoint.SeenIDs += posts
+	if result.HighestIDSeen > checkpoint.HighestID {
+		checkpoint.HighestID = result.HighestIDSeen
+	}
+	if err := checkpoint.save(s.checkpointPath); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
+
+// scrapeHistoricalFront stores the front page of each day in the range,
+// ranks included. maxPages caps the number of days. a day that fails is
+// recorded and skipped so one bad page doesn't end the backfill.
+func (s *SmartScraper) scrapeHistoricalFront(result *ScrapingResult) error {
+	if s.fromDay.IsZero() || s.toDay.IsZero() {
+		return fmt.Errorf("historical front scrape needs a date range")
+	}
+	if s.toDay.Before(s.fromDay) {
+		return fmt.Errorf("date range ends (%s) before it starts (%s)",
+			s.toDay.Format("2006-01-02"), s.fromDay.Format("2006-01-02"))
+	}
+
+	page := 0
+	for day := s.fromDay; !day.After(s.toDay); day = day.AddDate(0, 0, 1) {
+		if page == s.maxPages {
+			log.Printf("Reached the limit of %d days, stopping before %s", s.maxPages, day.Format("2006-01-02"))
+			result.StopReason = fmt.Sprintf("stopped: %d day limit", s.maxPages)
+			break
+		}
+		if s.maxDuration > 0 && time.Since(result.StartTime) >= s.maxDuration {
+			log.Printf("Time limit of %s reached before %s, stopping", s.maxDuration, day.Format("2006-01-02"))
+			result.StopReason = "stopped: time limit"
+			break
+		}
+		if page > 0 {
+			time.Sleep(2 * time.Second)
+		}
+		page++
+
+		posts, err := s.scrapePage(s.frontDayURL(day), page, result)
+		if err != nil {
+			log.Printf("Error scraping front page of %s: %v", day.Format("2006-01-02"), err)
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", day.Format("2006-01-02"), err))
+			continue
+		}
+		if len(posts) == 0 {
+			log.Printf("No posts on the front page of %s", day.Format("2006-01-02"))
+			continue
+		}
+
+		saved := s.savePosts(posts, result)
+		result.PostsScraped += saved
+		result.PagesScraped++
+	}
+
+	return nil
+}
+
+// frontDayURL is the archived front page for day on the configured site
+func (s *SmartScraper) frontDayURL(day time.Time) string {
+	base := "https://news.ycombinator.com"
+	if u, err := url.Parse(s.config.URL); err == nil && u.Host != "" {
+		base = u.Scheme + "://" + u.Host
+	}
+	return fmt.Sprintf("%s/front?day=%s", base, day.Format("2006-01-02"))
+}
+
+const defaultDuplicateThreshold = 5
+
+func (s *SmartScraper) duplicateThreshold() int {
+	if s.config.DuplicateThreshold > 0 {
+		return s.config.DuplicateThreshold
+	}
+	return defaultDuplicateThreshold
+}
+
 func (s *SmartScraper) scrapeUntilExisting(result *ScrapingResult) error {
 	duplicateCount := 0
-	duplicateThreshold := 5
+	duplicateThreshold := s.duplicateThreshold()
 	consecutiveEmptyPages := 0
+	// posts shift down while we page, so one we stored on page 1 can show up
+	// again on page 2. those aren't evidence of reaching the previous run.
+	processed := make(map[int]bool)
 	
 	for page := 1; page <= s.maxPages; page++ {
 		url := s.buildPageURL(page)
-		posts, err := s.scrapePage(url, page)
+		posts, err := s.scrapePage(url, page, result)
 		if err != nil {
 			log.Printf("Error scraping page %d: %v", page, err)
 			result.Errors = append(result.Errors, fmt.Sprintf("Page %d: %v", page, err))
@@ -282,27 +790,40 @@ func (s *SmartScraper) scrapeUntilExisting(result *ScrapingResult) error {
 		}
 		consecutiveEmptyPages = 0
 		
+		insertStart := time.Now()
+		existing, err := s.repo.FilterExistingIDs(postIDs(posts))
+		if err != nil {
+			log.Printf("Error checking existing posts on page %d: %v", page, err)
+			result.Errors = append(result.Errors, fmt.Sprintf("Page %d: %v", page, err))
+			break
+		}
+
 		newPosts := 0
 		for _, post := range posts {
-			exists, err := s.repo.PostExists(post.HnID)
-			if err != nil {
+			if processed[post.HnID] {
 				continue
 			}
-			
-			if exists {
+			processed[post.HnID] = true
+
+			if existing[post.HnID] {
 				duplicateCount++
 				if duplicateCount >= duplicateThreshold {
 					log.Printf("Found %d duplicates in a row, stopping", duplicateThreshold)
+					result.InsertTime += time.Since(insertStart)
 					return nil
 				}
 			} else {
 				duplicateCount = 0
-				if err := s.repo.InsertPost(&post); err == nil {
+				if _, err := s.insertPost(&post); err != nil {
+					result.insertFailed(post, err)
+				} else {
 					newPosts++
 					result.NewPosts++
+					s.checkAlert(&post, result)
 				}
 			}
 		}
+		result.InsertTime += time.Since(insertStart)
 		
 		result.PostsScraped += newPosts
 		result.PagesScraped = page
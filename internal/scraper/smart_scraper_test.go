@@ -0,0 +1,359 @@
+package scraper
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/dzmitry-papkou/scraper/internal/config"
+	"github.com/dzmitry-papkou/scraper/internal/models"
+)
+
+// hnPage renders a listing in HN's markup with one post per id, and a
+// "More" link to next when it isn't empty
+func hnPage(ids []int, next string) string {
+	var b strings.Builder
+	b.WriteString("<html><body><table>\n")
+	for i, id := range ids {
+		fmt.Fprintf(&b, `<tr class="athing" id="%d"><td><span class="rank">%d.</span></td>`+
+			`<td><span class="titleline"><a href="https://example.com/%d">Post %d</a></span></td></tr>`+"\n",
+			id, i+1, id, id)
+		fmt.Fprintf(&b, `<tr><td class="subtext"><span class="score">%d points</span> by <a class="hnuser">user%d</a> `+
+			`<span class="age" title="2024-05-01T12:00:00 1714564800"><a>1 hour ago</a></span> | <a>3&nbsp;comments</a></td></tr>`+"\n",
+			id%50, id)
+	}
+	b.WriteString("</table>\n")
+	if next != "" {
+		fmt.Fprintf(&b, `<a class="morelink" href="%s" rel="next">More</a>`+"\n", next)
+	}
+	b.WriteString("</body></html>\n")
+	return b.String()
+}
+
+// idRange lists ids from high down to low, newest first like a listing
+func idRange(high, low int) []int {
+	var ids []int
+	for id := high; id >= low; id-- {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// listingServer serves pages[n] at /newest?page=n (page 1 without the
+// query) and records every request URI in order
+type listingServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []string
+}
+
+func newListingServer(t *testing.T, pages map[int]string) *listingServer {
+	t.Helper()
+	ls := &listingServer{}
+	ls.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ls.mu.Lock()
+		ls.requests = append(ls.requests, r.URL.RequestURI())
+		ls.mu.Unlock()
+
+		page := 1
+		if p := r.URL.Query().Get("page"); p != "" {
+			fmt.Sscanf(p, "%d", &page)
+		}
+		body, ok := pages[page]
+		if !ok {
+			body = hnPage(nil, "")
+		}
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(ls.Close)
+	return ls
+}
+
+func (ls *listingServer) requested() []string {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	return append([]string(nil), ls.requests...)
+}
+
+func newTestSmartScraper(t *testing.T, ls *listingServer, mode ScrapingMode, maxPages int, existing ...int) (*SmartScraper, *stubStore) {
+	t.Helper()
+	repo, store := newStubRepo(t, existing...)
+	scraperConfig := &config.ScraperConfig{
+		Name:               "stub",
+		URL:                ls.URL + "/newest",
+		DuplicateThreshold: 3,
+	}
+	s, err := NewSmartScraper(repo, scraperConfig, mode, maxPages)
+	if err != nil {
+		t.Fatalf("NewSmartScraper: %v", err)
+	}
+	s.SetHTTPClient(ls.Client())
+	return s, store
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestScrapeUntilExistingStopsAtDuplicateThreshold(t *testing.T) {
+	// page 2 opens with two stored posts, one short of the threshold, so
+	// the scrape carries on; the third stored post in a row on page 3 stops
+	// it before 107 is reached
+	pages := map[int]string{
+		1: hnPage(idRange(130, 121), "newest?page=2"),
+		2: hnPage(idRange(120, 111), "newest?page=3"),
+		3: hnPage(idRange(110, 101), "newest?page=4"),
+		4: hnPage(idRange(100, 91), ""),
+	}
+	ls := newListingServer(t, pages)
+	s, store := newTestSmartScraper(t, ls, ModeUntilExisting, 10, 120, 119, 110, 109, 108)
+
+	result := &ScrapingResult{}
+	if err := s.scrapeUntilExisting(result); err != nil {
+		t.Fatalf("scrapeUntilExisting: %v", err)
+	}
+
+	if result.NewPosts != 18 {
+		t.Errorf("NewPosts = %d, want 18 (130-121 and 118-111)", result.NewPosts)
+	}
+	if store.has(107) {
+		t.Error("post 107 was stored, but the scrape should have stopped at 108")
+	}
+	want := []string{"/newest", "/newest?page=2", "/newest?page=3"}
+	if got := ls.requested(); !equalStrings(got, want) {
+		t.Errorf("requested %v, want %v", got, want)
+	}
+}
+
+func TestScrapeUntilExistingIgnoresPostsSeenThisRun(t *testing.T) {
+	// posts shift down while paging, so 121-123 show up again on page 2;
+	// they were stored by this run and must not count as duplicates
+	pages := map[int]string{
+		1: hnPage(idRange(130, 121), "newest?page=2"),
+		2: hnPage(append(idRange(123, 121), idRange(120, 114)...), ""),
+	}
+	ls := newListingServer(t, pages)
+	s, _ := newTestSmartScraper(t, ls, ModeUntilExisting, 2)
+
+	result := &ScrapingResult{}
+	if err := s.scrapeUntilExisting(result); err != nil {
+		t.Fatalf("scrapeUntilExisting: %v", err)
+	}
+	if result.NewPosts != 17 {
+		t.Errorf("NewPosts = %d, want 17", result.NewPosts)
+	}
+}
+
+func TestCatchUpWindowsCountOnlyInsertedPosts(t *testing.T) {
+	// 121-123 shift from the first window into the second, and 125 was
+	// stored by another run after lastKnownID was read; neither is new
+	pages := map[int]string{
+		1: hnPage(idRange(130, 121), "newest?page=2"),
+		2: hnPage(append(idRange(123, 121), idRange(120, 114)...), ""),
+	}
+	ls := newListingServer(t, pages)
+	s, _ := newTestSmartScraper(t, ls, ModeCatchUp, 2, 125)
+
+	result := &ScrapingResult{}
+	seen := make(map[int]bool)
+	for page := 1; page <= 2; page++ {
+		if _, err := s.scrapeSinceLastPages(result, 100, page, page, seen); err != nil {
+			t.Fatalf("window %d: %v", page, err)
+		}
+	}
+	if result.NewPosts != 16 {
+		t.Errorf("NewPosts = %d, want 16", result.NewPosts)
+	}
+	if result.PostsScraped != 16 {
+		t.Errorf("PostsScraped = %d, want 16", result.PostsScraped)
+	}
+}
+
+func TestScrapeFullArchiveStopsOnEmptyPage(t *testing.T) {
+	pages := map[int]string{
+		1: hnPage(idRange(130, 121), "newest?page=2"),
+		2: hnPage(idRange(120, 111), "newest?page=3"),
+		3: hnPage(nil, ""),
+		4: hnPage(idRange(110, 101), ""),
+	}
+	ls := newListingServer(t, pages)
+	s, store := newTestSmartScraper(t, ls, ModeFullArchive, 10)
+
+	result := &ScrapingResult{}
+	if err := s.scrapeFullArchive(result); err != nil {
+		t.Fatalf("scrapeFullArchive: %v", err)
+	}
+
+	if result.PagesScraped != 2 {
+		t.Errorf("PagesScraped = %d, want 2", result.PagesScraped)
+	}
+	if result.NewPosts != 20 {
+		t.Errorf("NewPosts = %d, want 20", result.NewPosts)
+	}
+	if store.has(110) {
+		t.Error("post 110 from past the empty page was stored")
+	}
+	want := []string{"/newest", "/newest?page=2", "/newest?page=3"}
+	if got := ls.requested(); !equalStrings(got, want) {
+		t.Errorf("requested %v, want %v", got, want)
+	}
+}
+
+func TestScrapeFullArchiveKeepsCheckpointAtPageCap(t *testing.T) {
+	pages := map[int]string{
+		1: hnPage(idRange(130, 121), "newest?page=2"),
+		2: hnPage(idRange(120, 111), "newest?page=3"),
+		3: hnPage(idRange(110, 101), "newest?page=4"),
+	}
+	ls := newListingServer(t, pages)
+	checkpointPath := filepath.Join(t.TempDir(), "checkpoint.json")
+
+	s, _ := newTestSmartScraper(t, ls, ModeFullArchive, 2)
+	s.SetCheckpoint(checkpointPath, false)
+	if err := s.scrapeFullArchive(&ScrapingResult{StartTime: time.Now()}); err != nil {
+		t.Fatalf("scrapeFullArchive: %v", err)
+	}
+	checkpoint, err := LoadCheckpoint(checkpointPath)
+	if err != nil || checkpoint == nil {
+		t.Fatalf("checkpoint after the page cap = %v, %v, want one at page 2", checkpoint, err)
+	}
+	if checkpoint.LastPage != 2 {
+		t.Errorf("checkpoint LastPage = %d, want 2", checkpoint.LastPage)
+	}
+
+	// the resumed run gets its own two pages, reaches the empty page 4 and
+	// so has walked the whole archive
+	s, store := newTestSmartScraper(t, ls, ModeFullArchive, 2)
+	s.SetCheckpoint(checkpointPath, true)
+	if err := s.scrapeFullArchive(&ScrapingResult{StartTime: time.Now()}); err != nil {
+		t.Fatalf("resumed scrapeFullArchive: %v", err)
+	}
+	if !store.has(101) {
+		t.Error("resumed run did not store page 3")
+	}
+	if checkpoint, _ := LoadCheckpoint(checkpointPath); checkpoint != nil {
+		t.Errorf("checkpoint left at page %d after the archive ended", checkpoint.LastPage)
+	}
+}
+
+func TestBuildPageURL(t *testing.T) {
+	tests := []struct {
+		base string
+		page int
+		want string
+	}{
+		{"https://news.ycombinator.com/newest", 1, "https://news.ycombinator.com/"},
+		{"https://news.ycombinator.com/newest", 3, "https://news.ycombinator.com/?p=3"},
+		{"https://example.com/newest", 1, "https://example.com/newest"},
+		{"https://example.com/newest", 2, "https://example.com/newest?page=2"},
+	}
+	for _, tt := range tests {
+		s := &SmartScraper{config: &config.ScraperConfig{URL: tt.base}}
+		if got := s.buildPageURL(tt.page); got != tt.want {
+			t.Errorf("buildPageURL(%d) with %s = %q, want %q", tt.page, tt.base, got, tt.want)
+		}
+	}
+}
+
+// the page loops build page URLs rather than parsing the "More" link, so
+// the URL for page n+1 must be where page n's link points
+func TestBuildPageURLMatchesMoreLink(t *testing.T) {
+	pages := map[int]string{
+		1: hnPage(idRange(130, 121), "newest?page=2"),
+		2: hnPage(idRange(120, 111), "newest?page=3"),
+		3: hnPage(idRange(110, 101), ""),
+	}
+	ls := newListingServer(t, pages)
+	s, _ := newTestSmartScraper(t, ls, ModeFullArchive, 10)
+
+	for page := 1; ; page++ {
+		pageURL := s.buildPageURL(page)
+		resp, err := ls.Client().Get(pageURL)
+		if err != nil {
+			t.Fatalf("fetching page %d: %v", page, err)
+		}
+		doc, err := goquery.NewDocumentFromReader(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("parsing page %d: %v", page, err)
+		}
+
+		href, ok := doc.Find("a.morelink").Attr("href")
+		if !ok {
+			if page != 3 {
+				t.Fatalf("page %d has no More link", page)
+			}
+			break
+		}
+
+		base, _ := url.Parse(pageURL)
+		next, err := base.Parse(href)
+		if err != nil {
+			t.Fatalf("page %d More link %q: %v", page, href, err)
+		}
+		if want := s.buildPageURL(page + 1); next.String() != want {
+			t.Errorf("page %d More link resolves to %s, buildPageURL(%d) = %s", page, next, page+1, want)
+		}
+	}
+}
+
+func TestConstructorsRejectUnknownProcessor(t *testing.T) {
+	scraperConfig := &config.ScraperConfig{Name: "stub", Processors: []string{"domain", "nope"}}
+
+	if _, err := NewWithConfig(nil, scraperConfig); err == nil {
+		t.Error("NewWithConfig accepted an unknown processor")
+	}
+	if _, err := NewSmartScraper(nil, scraperConfig, ModeLatestOnly, 1); err == nil {
+		t.Error("NewSmartScraper accepted an unknown processor")
+	}
+
+	scraperConfig.Processors = []string{"domain", "type"}
+	if _, err := NewWithConfig(nil, scraperConfig); err != nil {
+		t.Errorf("NewWithConfig with registered processors: %v", err)
+	}
+}
+
+func TestInsertPostQueuesFirstSnapshot(t *testing.T) {
+	repo, _ := newStubRepo(t)
+	s, err := NewSmartScraper(repo, &config.ScraperConfig{Name: "stub"}, ModeLatestOnly, 1)
+	if err != nil {
+		t.Fatalf("NewSmartScraper: %v", err)
+	}
+	FlushHistory()
+	t.Cleanup(func() { FlushHistory() })
+
+	post := models.Post{HnID: 7, Title: "new post", Points: 12, CommentsCount: 3}
+	if _, err := s.insertPost(&post); err != nil {
+		t.Fatalf("insertPost: %v", err)
+	}
+	partial := models.Post{HnID: 8, Title: "no score yet", Partial: true}
+	if _, err := s.insertPost(&partial); err != nil {
+		t.Fatalf("insertPost: %v", err)
+	}
+
+	pendingHistory.Lock()
+	snapshots := pendingHistory.snapshots
+	pendingHistory.Unlock()
+	if len(snapshots) != 1 {
+		t.Fatalf("queued %d snapshots, want 1", len(snapshots))
+	}
+	if got := snapshots[0]; got.postID != post.ID || got.points != 12 || got.comments != 3 {
+		t.Errorf("snapshot = {post %d, %d points, %d comments}, want {post %d, 12 points, 3 comments}",
+			got.postID, got.points, got.comments, post.ID)
+	}
+}
@@ -0,0 +1,146 @@
+package scraper
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dzmitry-papkou/scraper/internal/database"
+)
+
+// stubStore is an in-memory posts table behind the "scraperstub" driver. it
+// answers only the statements the page loops issue: the existing-id lookup
+// and the upsert; everything else succeeds without rows.
+type stubStore struct {
+	mu     sync.Mutex
+	posts  map[int64]bool
+	nextID int64
+}
+
+var (
+	stubRegister sync.Once
+	stub         *stubStore
+)
+
+// newStubRepo points the database package at a fresh stub store holding
+// the given hn ids and returns a repository on it
+func newStubRepo(t *testing.T, existing ...int) (*database.Repository, *stubStore) {
+	t.Helper()
+	stubRegister.Do(func() { sql.Register("scraperstub", stubDriver{}) })
+
+	stub = &stubStore{posts: make(map[int64]bool)}
+	for _, id := range existing {
+		stub.posts[int64(id)] = true
+	}
+
+	conn, err := sql.Open("scraperstub", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	database.UseDB(conn)
+	return database.NewRepository(), stub
+}
+
+func (s *stubStore) has(hnID int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.posts[int64(hnID)]
+}
+
+func (s *stubStore) query(query string, args []driver.Value) (driver.Rows, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case strings.Contains(query, "= ANY($1)"):
+		rows := &stubRows{columns: []string{"hn_id"}}
+		for _, id := range parseIntArray(args[0]) {
+			if s.posts[id] {
+				rows.values = append(rows.values, []driver.Value{id})
+			}
+		}
+		return rows, nil
+
+	case strings.Contains(query, "INSERT INTO"):
+		hnID := args[0].(int64)
+		rows := &stubRows{columns: []string{"id", "scraped_at", "points", "comments_count", "inserted", "title"}}
+		if s.posts[hnID] && strings.Contains(query, "DO NOTHING") {
+			return rows, nil
+		}
+		inserted := !s.posts[hnID]
+		s.posts[hnID] = true
+		s.nextID++
+		rows.values = append(rows.values, []driver.Value{s.nextID, time.Now(), args[4], args[5], inserted, nil})
+		return rows, nil
+	}
+	return &stubRows{}, nil
+}
+
+// parseIntArray reads a pq.Array value such as "{1,2,3}"
+func parseIntArray(v driver.Value) []int64 {
+	var text string
+	switch v := v.(type) {
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	}
+	var ids []int64
+	for _, field := range strings.Split(strings.Trim(text, "{}"), ",") {
+		if id, err := strconv.ParseInt(field, 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+type stubDriver struct{}
+
+func (stubDriver) Open(string) (driver.Conn, error) { return stubConn{}, nil }
+
+type stubConn struct{}
+
+func (stubConn) Prepare(query string) (driver.Stmt, error) { return stubStmt{query}, nil }
+func (stubConn) Close() error                              { return nil }
+func (stubConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported by the stub")
+}
+
+type stubStmt struct {
+	query string
+}
+
+func (stubStmt) Close() error  { return nil }
+func (stubStmt) NumInput() int { return -1 }
+
+func (s stubStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+
+func (s stubStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return stub.query(s.query, args)
+}
+
+type stubRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *stubRows) Columns() []string { return r.columns }
+func (r *stubRows) Close() error      { return nil }
+
+func (r *stubRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
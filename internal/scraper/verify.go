@@ -0,0 +1,125 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// thresholds a healthy HN front page comfortably clears; a markup change
+// usually drops the parsed count to zero or leaves fields empty
+const (
+	verifyMinPosts     = 20
+	verifyMaxPoints    = 100000
+	verifyMinTitleRate = 0.9
+)
+
+type VerifyCheck struct {
+	Name   string
+	Passed bool
+	Detail string
+}
+
+type VerifyReport struct {
+	URL    string
+	Posts  int
+	Checks []VerifyCheck
+	// where the fetched page was saved when a check failed
+	SavedHTML string
+}
+
+func (r *VerifyReport) Passed() bool {
+	for _, check := range r.Checks {
+		if !check.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// Verify fetches the live front page and checks that the parser still
+// finds a plausible set of posts, without storing anything
+func (s *Scraper) Verify() (*VerifyReport, error) {
+	report := &VerifyReport{URL: s.frontPageURL()}
+
+	body, err := fetchBody(context.Background(), s.client, s.config, report.URL)
+	if err != nil {
+		return report, err
+	}
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		report.saveHTML(s.config.Name, body)
+		return report, fmt.Errorf("failed to parse page: %w", err)
+	}
+	posts, err := s.parser.ParseDocument(doc)
+	if err != nil {
+		report.saveHTML(s.config.Name, body)
+		return report, err
+	}
+	report.Posts = len(posts)
+
+	titled, scored, implausible := 0, 0, 0
+	for _, post := range posts {
+		if post.Title != "" {
+			titled++
+		}
+		if post.Points > 0 {
+			scored++
+		}
+		if post.Points < 0 || post.Points > verifyMaxPoints {
+			implausible++
+		}
+	}
+
+	titleRate := 0.0
+	if len(posts) > 0 {
+		titleRate = float64(titled) / float64(len(posts))
+	}
+
+	report.Checks = []VerifyCheck{
+		{
+			Name:   "post count",
+			Passed: len(posts) >= verifyMinPosts,
+			Detail: fmt.Sprintf("%d posts parsed, need at least %d", len(posts), verifyMinPosts),
+		},
+		{
+			Name:   "titles",
+			Passed: len(posts) > 0 && titleRate >= verifyMinTitleRate,
+			Detail: fmt.Sprintf("%d of %d posts have a title", titled, len(posts)),
+		},
+		{
+			// front page posts all have votes, so zero scored means the
+			// score selector stopped matching
+			Name:   "scores",
+			Passed: scored > 0 && implausible == 0,
+			Detail: fmt.Sprintf("%d posts scored, %d outside 0-%d", scored, implausible, verifyMaxPoints),
+		},
+	}
+
+	if !report.Passed() {
+		report.saveHTML(s.config.Name, body)
+	}
+
+	return report, nil
+}
+
+// saveHTML keeps the page that failed verification for diagnosis
+func (r *VerifyReport) saveHTML(scraperName string, body []byte) {
+	path, err := saveDebugHTML(scraperName, body)
+	if err != nil {
+		log.Printf("Warning: Could not save page HTML: %v", err)
+		return
+	}
+	r.SavedHTML = path
+}
+
+func (s *Scraper) frontPageURL() string {
+	if u, err := url.Parse(s.config.URL); err == nil && u.Host != "" {
+		return u.Scheme + "://" + u.Host + "/"
+	}
+	return "https://news.ycombinator.com/"
+}